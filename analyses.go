@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Analysis is a named summary of Logs that the command line can print.
+// Run returns a value that is printed with fmt and encoded with encoding/json
+type Analysis struct {
+	Name  string
+	Label string
+	Run   func(logs *Logs) interface{}
+}
+
+// FirstFailure is the transaction that logged the earliest error
+type FirstFailure struct {
+	TransactionID string    `json:"transaction_id"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// String formats FirstFailure as the transaction ID followed by the time of the error
+func (failure FirstFailure) String() string {
+	return fmt.Sprintf("%s (%s)", failure.TransactionID, failure.Timestamp.Format(TimestampLayout))
+}
+
+// TopN is the number of transactions listed by the longest-transactions analysis
+var TopN = 5
+
+// MessageCount is the number of times a log message appears
+type MessageCount struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+// String formats MessageCount as the quoted message followed by its count
+func (message MessageCount) String() string {
+	return fmt.Sprintf("%q (%d)", message.Message, message.Count)
+}
+
+// Analyses lists every summary in the order it is printed
+var Analyses = []Analysis{
+	{"total", "Total Log Entries", func(logs *Logs) interface{} {
+		return logs.Occurrences()
+	}},
+	{"level-counts", "Logs by Level", func(logs *Logs) interface{} {
+		return logs.CountBy(func(log *Log) string { return log.Level })
+	}},
+	{"service-counts", "Logs by Service", func(logs *Logs) interface{} {
+		return logs.CountBy(func(log *Log) string { return log.Service })
+	}},
+	{"longest-transaction", "Longest Transaction", func(logs *Logs) interface{} {
+		return logs.LongestTransaction()
+	}},
+	{"longest-transactions", "Longest Transactions", func(logs *Logs) interface{} {
+		return TransactionRanking(logs.TopLongestTransactions(TopN))
+	}},
+	{"median-duration", "Median Transaction Duration", func(logs *Logs) interface{} {
+		return logs.MedianTransactionDuration()
+	}},
+	{"error-count", "Operation with Most Errors", func(logs *Logs) interface{} {
+		return logs.OperationWithMostErrors()
+	}},
+	{"operation-errors", "Errors by Operation", func(logs *Logs) interface{} {
+		return logs.ErrorCounts()
+	}},
+	{"error-gini", "Error Concentration (Gini)", func(logs *Logs) interface{} {
+		return logs.ErrorConcentrationGini()
+	}},
+	{"error-rate", "Error Rate", func(logs *Logs) interface{} {
+		return logs.ErrorRate()
+	}},
+	{"common-error", "Most Common Error Message", func(logs *Logs) interface{} {
+		message, count := logs.MostCommonErrorMessage()
+		return MessageCount{Message: message, Count: count}
+	}},
+	{"level-matrix", "Levels by Service", func(logs *Logs) interface{} {
+		return logs.LevelCountsByService()
+	}},
+	{"first-failure", "First Failing Transaction", func(logs *Logs) interface{} {
+		id, at := logs.FirstFailingTransaction()
+		return FirstFailure{TransactionID: id, Timestamp: at}
+	}},
+	{"volume-series", "Logs per Interval", func(logs *Logs) interface{} {
+		return seriesResult(logs.DenseCountSeries(Interval))
+	}},
+	{"error-series", "Errors per Interval", func(logs *Logs) interface{} {
+		return seriesResult(logs.CountSeries(Interval, (*Log).IsError))
+	}},
+	{"recent-errors", "Errors in the Final Interval", func(logs *Logs) interface{} {
+		return logs.RecentErrorConcentration(Interval)
+	}},
+	{"volume-error-correlation", "Volume and Error Rate Correlation", func(logs *Logs) interface{} {
+		// NaN cannot be encoded as JSON, so an undefined correlation has no value
+		correlation := logs.VolumeErrorCorrelation(Interval)
+		if math.IsNaN(correlation) {
+			return nil
+		}
+		return correlation
+	}},
+}
+
+// SelectAnalyses returns the Analyses named in a comma-separated list, keeping
+// the order they are printed in. An empty list selects every analysis
+func SelectAnalyses(names string) ([]Analysis, error) {
+	if names == "" {
+		return Analyses, nil
+	}
+	known := map[string]bool{}
+	for _, analysis := range Analyses {
+		known[analysis.Name] = true
+	}
+	requested := map[string]bool{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if !known[name] {
+			return nil, fmt.Errorf("unknown analysis %q", name)
+		}
+		requested[name] = true
+	}
+	selected := []Analysis{}
+	for _, analysis := range Analyses {
+		if requested[analysis.Name] {
+			selected = append(selected, analysis)
+		}
+	}
+	return selected, nil
+}
+
+// Result is the value an Analysis produced
+type Result struct {
+	Name  string
+	Label string
+	Value interface{}
+}
+
+// Results are the outputs of the Analyses, in the order they were run
+type Results []Result
+
+// RunAnalyses runs each analysis over logs, returning the Results in order
+func RunAnalyses(analyses []Analysis, logs *Logs) Results {
+	results := Results{}
+	for _, analysis := range analyses {
+		results = append(results, Result{analysis.Name, analysis.Label, analysis.Run(logs)})
+	}
+	return results
+}
+
+// List is a list of names, formatted as a comma-separated list
+type List []string
+
+// String formats List as a comma-separated list
+func (list List) String() string {
+	return strings.Join(list, ", ")
+}
+
+// TransactionAnalyses summarize the Logs of a single transaction
+var TransactionAnalyses = []Analysis{
+	{"transaction", "Transaction", func(logs *Logs) interface{} {
+		return (*logs)[0].TransactionID
+	}},
+	{"duration", "Duration", func(logs *Logs) interface{} {
+		duration, ok := MeasureTransaction(*logs)
+		if !ok {
+			return nil
+		}
+		return duration
+	}},
+	{"log-count", "Log Entries", func(logs *Logs) interface{} {
+		return logs.Occurrences()
+	}},
+	{"services", "Services", func(logs *Logs) interface{} {
+		services := List{}
+		seen := map[string]bool{}
+		for _, log := range *logs {
+			if !seen[log.Service] {
+				seen[log.Service] = true
+				services = append(services, log.Service)
+			}
+		}
+		return services
+	}},
+	{"operations", "Operations", func(logs *Logs) interface{} {
+		operations := List{}
+		for _, log := range *logs {
+			if log.IsStart() {
+				operations = append(operations, log.Operation)
+			}
+		}
+		return operations
+	}},
+	{"error-count", "Errors", func(logs *Logs) interface{} {
+		errorLogs := logs.Filter((*Log).IsError)
+		return errorLogs.Occurrences()
+	}},
+	{"first-error", "First Error", func(logs *Logs) interface{} {
+		errorLogs := logs.Filter((*Log).IsError)
+		if len(errorLogs) == 0 {
+			return nil
+		}
+		return errorLogs[0].Timestamp.Time
+	}},
+}
+
+// TransactionReport runs the TransactionAnalyses over the Logs of transaction
+// id, sorted by Timestamp, returning false if there are none
+func (logs *Logs) TransactionReport(id string) (Report, bool) {
+	list := logs.Transaction(id)
+	if len(list) == 0 {
+		return Report{}, false
+	}
+	return Report{SchemaVersion: SchemaVersion, Results: RunAnalyses(TransactionAnalyses, &list)}, true
+}
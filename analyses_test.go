@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectAnalyses(t *testing.T) {
+	analyses, err := SelectAnalyses("error-count, total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, analysis := range analyses {
+		names = append(names, analysis.Name)
+	}
+	// Analyses are run in their usual order, not the order requested
+	if want := []string{"total", "error-count"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
+
+func TestSelectAnalysesUnknown(t *testing.T) {
+	if _, err := SelectAnalyses("total,latency"); err == nil {
+		t.Error("got no error for an unknown analysis")
+	}
+}
+
+func TestTransactionReport(t *testing.T) {
+	logs := Logs{
+		newLog("a", "loadbalancer", "GET", "INFO", "START /account requested", 0),
+		newLog("a", "webserver", "/account", "INFO", "START Retrieving account information", time.Second),
+		newLog("a", "account_service", "GetAccount", "ERROR", "Account locked", 2*time.Second),
+		newLog("b", "loadbalancer", "GET", "ERROR", "Bad request", 2*time.Second),
+		newLog("a", "webserver", "/account", "ERROR", "END Retrieving account information", 3*time.Second),
+		newLog("a", "loadbalancer", "GET", "INFO", "END /account requested", 4*time.Second),
+	}
+	report, ok := logs.TransactionReport("a")
+	if !ok {
+		t.Fatal("got no report for transaction a")
+	}
+	want := Results{
+		{"transaction", "Transaction", "a"},
+		{"duration", "Duration", 4 * time.Second},
+		{"log-count", "Log Entries", 5},
+		{"services", "Services", List{"loadbalancer", "webserver", "account_service"}},
+		{"operations", "Operations", List{"GET", "/account"}},
+		{"error-count", "Errors", 2},
+		{"first-error", "First Error", testStart.Add(2 * time.Second)},
+	}
+	if !reflect.DeepEqual(report.Results, want) {
+		t.Errorf("got %v, want %v", report.Results, want)
+	}
+	if _, ok := logs.TransactionReport("missing"); ok {
+		t.Error("got a report for a missing transaction")
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+// benchmarkLogs is the size of the input the benchmarks measure
+const benchmarkLogs = 1000000
+
+// call is an operation run by a service, along with the calls it makes to
+// other services while it runs
+type call struct {
+	service   string
+	operation string
+	message   string
+	calls     []call
+}
+
+// requests are the call chains a generated transaction can follow, based on
+// the example input
+var requests = []call{
+	{"loadbalancer", "POST", "/login requested", []call{
+		{"webserver", "/login", "Logging in user", []call{
+			{"authentication_service", "AuthenticateUser", "Authenticating user", nil},
+		}},
+	}},
+	{"loadbalancer", "GET", "/index requested", []call{
+		{"webserver", "/index", "Unauthenticated request", []call{
+			{"loadbalancer", "GET", "/login requested", []call{
+				{"webserver", "/login", "Displaying login page", nil},
+			}},
+		}},
+	}},
+	{"loadbalancer", "GET", "/account requested", []call{
+		{"webserver", "/account", "Retrieving account information", []call{
+			{"account_service", "GetAccount", "Retrieving user account information", nil},
+		}},
+	}},
+}
+
+// generateLogs returns n synthetic logs shaped like the example input, in
+// time order. Transactions overlap, and the same n always produces the same logs
+func generateLogs(n int) Logs {
+	random := rand.New(rand.NewSource(1))
+	now := time.Date(2017, 10, 17, 0, 0, 0, 0, time.UTC)
+	logs := make(Logs, 0, n)
+	// Timestamps are only written to the microsecond
+	upToASecond := func() time.Duration {
+		return time.Duration(random.Int63n(int64(time.Second/time.Microsecond))) * time.Microsecond
+	}
+	var run func(id string, c call, at time.Time) time.Time
+	record := func(id string, c call, at time.Time, prefix string) {
+		if len(logs) < n {
+			logs = append(logs, Log{
+				Service:       c.service,
+				Level:         Levels[random.Intn(len(Levels))],
+				Timestamp:     Timestamp{at},
+				Operation:     c.operation,
+				Message:       prefix + c.message,
+				TransactionID: id,
+			})
+		}
+	}
+	run = func(id string, c call, at time.Time) time.Time {
+		record(id, c, at, StartPrefix)
+		for _, child := range c.calls {
+			at = run(id, child, at.Add(upToASecond()))
+		}
+		at = at.Add(upToASecond())
+		record(id, c, at, EndPrefix)
+		return at
+	}
+	for transaction := 0; len(logs) < n; transaction++ {
+		run(fmt.Sprintf("transaction-%d", transaction), requests[random.Intn(len(requests))], now)
+		now = now.Add(upToASecond())
+	}
+	// Transactions overlap, so their logs interleave once put in time order
+	sort.Stable(logs)
+	return logs
+}
+
+func BenchmarkParse(b *testing.B) {
+	data, err := json.Marshal(generateLogs(benchmarkLogs))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeLogs(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLongestTransaction(b *testing.B) {
+	logs := generateLogs(benchmarkLogs)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logs.LongestTransaction()
+	}
+}
+
+func BenchmarkAnalyze(b *testing.B) {
+	logs := generateLogs(benchmarkLogs)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RunAnalyses(Analyses, &logs)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// ErrorRate returns the fraction of Logs that are errors, or 0 for no Logs
+func (logs *Logs) ErrorRate() float64 {
+	if len(*logs) == 0 {
+		return 0
+	}
+	errorLogs := logs.Filter((*Log).IsError)
+	return float64(errorLogs.Occurrences()) / float64(logs.Occurrences())
+}
+
+// ErrorRateExcludingWarmup returns the error rate of Logs ignoring every log
+// written within warmup of the earliest timestamp, giving a steady-state
+// figure that is not inflated by startup noise
+func (logs *Logs) ErrorRateExcludingWarmup(warmup time.Duration) float64 {
+	earliest, _ := logs.TimeRange()
+	steady := logs.Filter(func(log *Log) bool {
+		return !log.Timestamp.Before(earliest.Add(warmup))
+	})
+	return steady.ErrorRate()
+}
+
+// ErrorTimeline returns every error log sorted by Timestamp. Errors logged at
+// the same time keep their original order
+func (logs *Logs) ErrorTimeline() Logs {
+	errorLogs := logs.Filter((*Log).IsError)
+	sort.Stable(errorLogs)
+	return errorLogs
+}
+
+// InterArrivalStats summarizes the time between consecutive events
+type InterArrivalStats struct {
+	Min  time.Duration `json:"min_ns"`
+	Mean time.Duration `json:"mean_ns"`
+	Max  time.Duration `json:"max_ns"`
+}
+
+// ErrorInterArrivalStats returns the min, mean and max time between consecutive
+// error logs across all Logs, for MTBF-style analysis. Returns false if fewer
+// than two errors were logged. Collapsed repeats count towards the mean, which
+// runs to the last of them, but their own times are unknown, so the min and
+// max only compare logged times
+func (logs *Logs) ErrorInterArrivalStats() (InterArrivalStats, bool) {
+	errorLogs := logs.ErrorTimeline()
+	if len(errorLogs) < 2 {
+		return InterArrivalStats{}, false
+	}
+	stats := InterArrivalStats{}
+	for i := 1; i < len(errorLogs); i++ {
+		gap := errorLogs[i].Timestamp.Sub(errorLogs[i-1].Timestamp.Time)
+		if i == 1 || gap < stats.Min {
+			stats.Min = gap
+		}
+		if gap > stats.Max {
+			stats.Max = gap
+		}
+	}
+	first, last := errorLogs.TimeRange()
+	stats.Mean = last.Sub(first) / time.Duration(errorLogs.Occurrences()-1)
+	return stats, true
+}
+
+// MostCommonErrorMessage returns the exact Message that appears most often
+// among error logs, and how many times it appears. Messages with equal counts
+// are ordered alphabetically, and the first is returned
+func (logs *Logs) MostCommonErrorMessage() (string, int) {
+	counts := map[string]int{}
+	for _, log := range *logs {
+		if log.IsError() {
+			counts[log.Message] += log.Occurrences()
+		}
+	}
+	mostCommon := ""
+	mostCount := 0
+	for message, count := range counts {
+		if count > mostCount || (count == mostCount && message < mostCommon) {
+			mostCommon = message
+			mostCount = count
+		}
+	}
+	return mostCommon, mostCount
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestErrorRateExcludingWarmup(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "ERROR", "Cache cold", 0),
+		newLog("a", "webserver", "/login", "ERROR", "Cache cold", 10*time.Second),
+		newLog("b", "webserver", "/login", "INFO", "START Logging in user", time.Minute),
+		newLog("b", "webserver", "/login", "ERROR", "Login failed", 2*time.Minute),
+		newLog("b", "webserver", "/login", "INFO", "END Logging in user", 3*time.Minute),
+		newLog("c", "webserver", "/login", "INFO", "START Logging in user", 4*time.Minute),
+	}
+	if got := logs.ErrorRate(); got != 0.5 {
+		t.Errorf("got %v including warm-up, want 0.5", got)
+	}
+	if got := logs.ErrorRateExcludingWarmup(time.Minute); got != 0.25 {
+		t.Errorf("got %v excluding warm-up, want 0.25", got)
+	}
+}
+
+func TestErrorInterArrivalStats(t *testing.T) {
+	logs := Logs{
+		newLog("b", "webserver", "/login", "ERROR", "Login failed", 4*time.Second),
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", 0),
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", 2*time.Second),
+		newLog("c", "webserver", "/login", "ERROR", "Login failed", 10*time.Second),
+	}
+	stats, ok := logs.ErrorInterArrivalStats()
+	want := InterArrivalStats{Min: 4 * time.Second, Mean: 5 * time.Second, Max: 6 * time.Second}
+	if !ok || stats != want {
+		t.Errorf("got %+v, %v, want %+v", stats, ok, want)
+	}
+	single := logs[:1]
+	if _, ok := single.ErrorInterArrivalStats(); ok {
+		t.Error("got stats for a single error")
+	}
+	repeated := Logs{newLog("a", "webserver", "/login", "ERROR", "Login failed", 0)}
+	repeated[0].Extra = map[string]int{RepeatsKey: 1}
+	if _, ok := repeated.ErrorInterArrivalStats(); ok {
+		t.Error("got stats for a single collapsed error")
+	}
+}
+
+func TestMostCommonErrorMessage(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", 0),
+		newLog("a", "webserver", "/login", "ERROR", "Timed out", time.Second),
+		newLog("b", "webserver", "/login", "ERROR", "Login failed", 2*time.Second),
+		newLog("b", "webserver", "/login", "INFO", "Timed out", 3*time.Second),
+		newLog("c", "webserver", "/login", "INFO", "Timed out", 4*time.Second),
+	}
+	if message, count := logs.MostCommonErrorMessage(); message != "Login failed" || count != 2 {
+		t.Errorf("got %q (%d), want \"Login failed\" (2)", message, count)
+	}
+	tied := logs[1:3]
+	if message, count := tied.MostCommonErrorMessage(); message != "Login failed" || count != 1 {
+		t.Errorf("got %q (%d) for a tie, want \"Login failed\" (1)", message, count)
+	}
+}
+
+func TestErrorTimeline(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", 3*time.Second),
+		newLog("b", "db_service", "GetUser", "ERROR", "Timed out", time.Second),
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", 0),
+		newLog("c", "loadbalancer", "GET", "ERROR", "Bad request", 2*time.Second),
+		newLog("b", "db_service", "GetUser", "ERROR", "Retry failed", 2*time.Second),
+	}
+	got := []string{}
+	for _, log := range logs.ErrorTimeline() {
+		got = append(got, log.TransactionID+": "+log.Message)
+	}
+	// Errors logged at the same time keep their original order
+	want := []string{"b: Timed out", "c: Bad request", "b: Retry failed", "a: Login failed"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
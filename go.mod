@@ -0,0 +1,3 @@
+module github.com/medhir/lightstep-challenge
+
+go 1.21
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// readCloser pairs a Reader that wraps an input with the Closer of that input
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Base64Input decodes inputs from base64 before decompressing and parsing them
+var Base64Input = false
+
+// OpenInput opens fileName for reading, decoding it if Base64Input is set and
+// decompressing it if its extension is .bz2. A fileName starting with http://
+// or https:// is fetched, and its body read as it arrives until ctx is done
+func OpenInput(ctx context.Context, fileName string) (io.ReadCloser, error) {
+	input, err := openRaw(ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+	var reader io.Reader = input
+	if Base64Input {
+		reader = base64.NewDecoder(base64.StdEncoding, reader)
+	}
+	if filepath.Ext(fileName) == ".bz2" {
+		reader = bzip2.NewReader(reader)
+	}
+	return readCloser{reader, input}, nil
+}
+
+// IsURL reports whether fileName is an http:// or https:// URL to fetch
+func IsURL(fileName string) bool {
+	return strings.HasPrefix(fileName, "http://") || strings.HasPrefix(fileName, "https://")
+}
+
+// openRaw opens fileName, or fetches it if it is a URL, without decoding it
+func openRaw(ctx context.Context, fileName string) (io.ReadCloser, error) {
+	if IsURL(fileName) {
+		return openURL(ctx, fileName)
+	}
+	return os.Open(fileName)
+}
+
+// openURL starts a GET request for url and returns its body
+func openURL(ctx context.Context, url string) (io.ReadCloser, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, response.Status)
+	}
+	return response.Body, nil
+}
+
+// ReadLogs reads and parses the Logs in fileName
+func ReadLogs(fileName string) (Logs, error) {
+	input, err := OpenInput(context.Background(), fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	return DecodeLogs(input)
+}
+
+// DecodeLogs parses Logs from either a JSON list or newline-delimited JSON,
+// where each line holds a single log. Newline-delimited logs are decoded one at
+// a time as they are read, so a stream can be consumed while it is still open
+func DecodeLogs(input io.Reader) (Logs, error) {
+	logs := Logs{}
+	err := DecodeEach(context.Background(), input, func(log *Log) {
+		logs = append(logs, *log)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// DecodeEach parses logs from either a JSON list or newline-delimited JSON,
+// calling handle with each log as soon as it is decoded, without holding
+// on to previous logs. Decoding stops with ctx.Err() once ctx is done
+func DecodeEach(ctx context.Context, input io.Reader, handle func(log *Log)) error {
+	reader := bufio.NewReader(input)
+	first, err := peekNonSpace(reader)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(reader)
+	if first == '[' {
+		// Step into the list so its elements can be decoded one at a time
+		// Based on: https://pkg.go.dev/encoding/json#example-Decoder.Decode-Stream
+		_, err = decoder.Token()
+		if err != nil {
+			return err
+		}
+	}
+	for decoder.More() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log := Log{}
+		err = decoder.Decode(&log)
+		if err != nil {
+			return err
+		}
+		handle(&log)
+	}
+	if first == '[' {
+		_, err = decoder.Token()
+	}
+	return err
+}
+
+// peekNonSpace discards leading whitespace from reader and returns the next
+// byte without consuming it
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		next, err := reader.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(rune(next[0])) {
+			return next[0], nil
+		}
+		reader.ReadByte()
+	}
+}
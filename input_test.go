@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadLogsBzip2(t *testing.T) {
+	plain, err := ReadLogs("testdata/logs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := ReadLogs("testdata/logs.json.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plain) == 0 || !reflect.DeepEqual(plain, compressed) {
+		t.Errorf("got %d logs from bzip2 input, want the same %d logs as plain input", len(compressed), len(plain))
+	}
+	if got, want := RunAnalyses(Analyses, &compressed), RunAnalyses(Analyses, &plain); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v from bzip2 input, want %v", got, want)
+	}
+}
+
+func TestReadLogsBase64(t *testing.T) {
+	defer func() { Base64Input = false }()
+	plain, err := ReadLogs("testdata/logs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile("testdata/logs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileName := filepath.Join(t.TempDir(), "logs.b64")
+	if err := os.WriteFile(fileName, []byte(base64.StdEncoding.EncodeToString(data)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	Base64Input = true
+	decoded, err := ReadLogs(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := RunAnalyses(Analyses, &decoded), RunAnalyses(Analyses, &plain); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v from base64 input, want %v", got, want)
+	}
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"sort"
 	"strings"
 	"time"
@@ -14,9 +16,19 @@ import (
 // TimestampLayout defines the format to parse timestamps into the time.Time tyep
 const TimestampLayout = "2006-01-02 15:04:05.000000"
 
+// TimestampLocation is the time zone timestamps are read in, since
+// TimestampLayout has no offset of its own
+var TimestampLocation = time.UTC
+
 // ErrorLevel is the string value for errors as determined by a log's "level" field
 const ErrorLevel = "ERROR"
 
+// StartPrefix begins the message an operation logs when it starts its work
+const StartPrefix = "START"
+
+// EndPrefix begins the message an operation logs when it ends its work
+const EndPrefix = "END"
+
 // Timestamp is used to parse JSON "timestamp" input into the time.Time type
 // Adapted from https://ustrajunior.com/blog/json-unmarshal-custom-date-formats/
 type Timestamp struct {
@@ -27,7 +39,7 @@ type Timestamp struct {
 func (t *Timestamp) UnmarshalJSON(input []byte) error {
 	strInput := string(input)
 	strInput = strings.Trim(strInput, `"`)
-	newTime, err := time.Parse(TimestampLayout, strInput)
+	newTime, err := time.ParseInLocation(TimestampLayout, strInput, TimestampLocation)
 	if err != nil {
 		return err
 	}
@@ -36,6 +48,17 @@ func (t *Timestamp) UnmarshalJSON(input []byte) error {
 	return nil
 }
 
+// MarshalJSON defines the interface for marshalling a Timestamp into the "timestamp" field,
+// in the same format it is unmarshalled from
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Format(TimestampLayout))
+}
+
+// String formats a Timestamp with TimestampLayout
+func (t Timestamp) String() string {
+	return t.Format(TimestampLayout)
+}
+
 // Log represents a single JSON-encoded log event
 type Log struct {
 	Service       string    `json:"service"`
@@ -44,6 +67,37 @@ type Log struct {
 	Operation     string    `json:"operation"`
 	Message       string    `json:"message"`
 	TransactionID string    `json:"transaction_id"`
+	// Extra holds numbers the analyzer derives for a log, such as RepeatsKey
+	Extra map[string]int `json:"extra,omitempty"`
+}
+
+// RepeatsKey is the Extra count of identical logs collapsed into a log
+const RepeatsKey = "repeats"
+
+// RepeatSpanKey is the Extra number of nanoseconds from a log to the last
+// identical log collapsed into it
+const RepeatSpanKey = "repeat_span_ns"
+
+// UnmarshalJSON defines the interface for unmarshalling a Log, accepting a
+// "trace_id" field in place of "transaction_id". When both are present,
+// "transaction_id" is preferred
+func (log *Log) UnmarshalJSON(input []byte) error {
+	// plainLog has the same fields as Log without its methods, so decoding
+	// into it does not recurse back into this function
+	type plainLog Log
+	fields := struct {
+		*plainLog
+		TraceID string `json:"trace_id"`
+	}{plainLog: (*plainLog)(log)}
+	err := json.Unmarshal(input, &fields)
+	if err != nil {
+		return err
+	}
+
+	if log.TransactionID == "" {
+		log.TransactionID = fields.TraceID
+	}
+	return nil
 }
 
 // IsError determines if a Log is an error according to its level
@@ -51,9 +105,113 @@ func (log *Log) IsError() bool {
 	return log.Level == ErrorLevel
 }
 
+// IsStart determines if a Log marks the start of an operation's work
+func (log *Log) IsStart() bool {
+	return strings.HasPrefix(log.Message, StartPrefix)
+}
+
+// IsEnd determines if a Log marks the end of an operation's work
+func (log *Log) IsEnd() bool {
+	return strings.HasPrefix(log.Message, EndPrefix)
+}
+
+// Occurrences returns the number of logs this Log stands for, including
+// any identical logs collapsed into it
+func (log *Log) Occurrences() int {
+	return log.Extra[RepeatsKey] + 1
+}
+
+// LastTimestamp returns when the last of the logs this Log stands for was
+// written, which is after Timestamp if identical logs were collapsed into it
+func (log *Log) LastTimestamp() time.Time {
+	return log.Timestamp.Add(time.Duration(log.Extra[RepeatSpanKey]))
+}
+
+// IsRepeatOf determines if a Log is an identical copy of another Log,
+// ignoring when it was written
+func (log *Log) IsRepeatOf(other *Log) bool {
+	return log.TransactionID == other.TransactionID &&
+		log.Service == other.Service &&
+		log.Operation == other.Operation &&
+		log.Message == other.Message &&
+		log.Level == other.Level
+}
+
 // Logs is a list of logs represented as a Go slice
 type Logs []Log
 
+// Predicate reports whether a Log should be kept by Filter
+type Predicate func(log *Log) bool
+
+// ByService returns a Predicate matching logs written by service
+func ByService(service string) Predicate {
+	return func(log *Log) bool {
+		return log.Service == service
+	}
+}
+
+// ByLevel returns a Predicate matching logs at any of levels
+func ByLevel(levels ...string) Predicate {
+	return func(log *Log) bool {
+		for _, level := range levels {
+			if log.Level == level {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ExcludeLevels returns a Predicate matching logs at none of the levels in a
+// comma-separated list, such as "DEBUG, INFO"
+func ExcludeLevels(list string) Predicate {
+	levels := strings.Split(list, ",")
+	for i := range levels {
+		levels[i] = strings.TrimSpace(levels[i])
+	}
+	return Not(ByLevel(levels...))
+}
+
+// Not returns a Predicate matching the logs that predicate does not
+func Not(predicate Predicate) Predicate {
+	return func(log *Log) bool {
+		return !predicate(log)
+	}
+}
+
+// Filter returns the Logs matching keep, in their original order
+func (logs *Logs) Filter(keep Predicate) Logs {
+	filtered := Logs{}
+	for i := range *logs {
+		if keep(&(*logs)[i]) {
+			filtered = append(filtered, (*logs)[i])
+		}
+	}
+	return filtered
+}
+
+// Occurrences returns the number of logs Logs stands for, including any
+// identical logs collapsed into them. Analyses count logs this way, so a
+// collapsed file reports the same counts as the original
+func (logs *Logs) Occurrences() int {
+	occurrences := 0
+	for i := range *logs {
+		occurrences += (*logs)[i].Occurrences()
+	}
+	return occurrences
+}
+
+// SplitAt returns the Logs written before at, and those written at or after it
+func (logs *Logs) SplitAt(at time.Time) (Logs, Logs) {
+	before := logs.Filter(func(log *Log) bool {
+		return log.Timestamp.Before(at)
+	})
+	after := logs.Filter(func(log *Log) bool {
+		return !log.Timestamp.Before(at)
+	})
+	return before, after
+}
+
 // Interface to sort Logs by timestamp
 // Based on: https://stackoverflow.com/questions/23121026/sorting-by-time-time-in-golang
 func (logs Logs) Len() int {
@@ -70,76 +228,383 @@ func (logs Logs) Swap(i, j int) {
 	logs[i], logs[j] = logs[j], logs[i]
 }
 
-// LongestTransaction returns a formatted string containing
-// the transaction with the longest duration, as determined by the first
-// and last timestamp within the Logs associated with a transaction
-func (logs *Logs) LongestTransaction() string {
-	var longestDuration time.Duration
-	longestTransaction := ""
-	transactions := map[string]Logs{}
-	// Create a map of Logs indexed by the log.TransactionID field
-	for _, log := range *logs {
-		transactions[log.TransactionID] = append(transactions[log.TransactionID], log)
-	}
-	for id, list := range transactions {
-		// Sort Logs by Timestamp
-		sort.Sort(list)
-		firstTime := list[0]
-		lastTime := list[len(list)-1]
-		// Get the duration between the first and last timestamp in transaction
-		// https://stackoverflow.com/questions/40260599/difference-between-two-time-time-objects/40260666
-		duration := lastTime.Timestamp.Sub(firstTime.Timestamp.Time)
-		if duration > longestDuration {
-			// Set longest duration if longer than duration seen so far
-			longestTransaction = id
-			longestDuration = duration
+// TimeRange returns the earliest and latest timestamps found in Logs, where
+// the latest is the LastTimestamp of a collapsed log
+func (logs *Logs) TimeRange() (time.Time, time.Time) {
+	var earliest, latest time.Time
+	for i := range *logs {
+		log := &(*logs)[i]
+		if i == 0 || log.Timestamp.Before(earliest) {
+			earliest = log.Timestamp.Time
+		}
+		if last := log.LastTimestamp(); i == 0 || last.After(latest) {
+			latest = last
 		}
 	}
-	return fmt.Sprintf("%s (%s)", longestTransaction, longestDuration)
+	return earliest, latest
+}
+
+// LongestTransaction returns the transaction with the longest duration,
+// as measured by MeasureTransaction: by default from the first START to the
+// last END within the Logs associated with a transaction
+func (logs *Logs) LongestTransaction() TransactionDuration {
+	longest := logs.TopLongestTransactions(1)
+	if len(longest) == 0 {
+		return TransactionDuration{}
+	}
+	return longest[0]
+}
+
+// OperationErrors is the number of errors logged by an operation
+type OperationErrors struct {
+	Operation string `json:"operation"`
+	Errors    int    `json:"errors"`
+}
+
+// String formats OperationErrors as the operation followed by its error count
+func (operation OperationErrors) String() string {
+	return fmt.Sprintf("%s (%d Errors)", operation.Operation, operation.Errors)
 }
 
-// OperationWithMostErrors returns a formatted string containing
-// the operation with the most errors (and its error count)
-func (logs *Logs) OperationWithMostErrors() string {
+// OperationWithMostErrors returns the operation with the most errors
+// (and its error count). Operations with equal counts are ordered
+// alphabetically, and the first is returned
+func (logs *Logs) OperationWithMostErrors() OperationErrors {
 	mostErrors := 0
 	var operationWithMostErrors string
-	// Create a map of Logs indexed by the log.Operation field
-	operations := map[string]Logs{}
+	// Set the operation with the most errors if greater than
+	// most errors seen thus far
+	for operation, numErrors := range logs.ErrorCounts() {
+		if numErrors > mostErrors || (numErrors == mostErrors && operation < operationWithMostErrors) {
+			operationWithMostErrors = operation
+			mostErrors = numErrors
+		}
+	}
+	return OperationErrors{Operation: operationWithMostErrors, Errors: mostErrors}
+}
+
+// DedupKeys identify logs that are copies of each other, by name.
+// "full" matches logs identical in every field, while "txn+ts" matches logs
+// sharing a transaction and timestamp, such as a log delivered twice with its
+// message altered in transit
+var DedupKeys = map[string]func(log *Log) interface{}{
+	"full": func(log *Log) interface{} {
+		// Extra is a map, which cannot be part of a key, so its counts are
+		// listed with the other fields instead
+		return struct {
+			service, level, operation, message, transactionID string
+			timestamp                                         time.Time
+			repeats, repeatSpan                               int
+		}{log.Service, log.Level, log.Operation, log.Message, log.TransactionID, log.Timestamp.Time, log.Extra[RepeatsKey], log.Extra[RepeatSpanKey]}
+	},
+	"txn+ts": func(log *Log) interface{} {
+		return struct {
+			transactionID string
+			timestamp     time.Time
+		}{log.TransactionID, log.Timestamp.Time}
+	},
+}
+
+// Dedup returns Logs keeping only the first log for each distinct key
+func (logs *Logs) Dedup(key func(log *Log) interface{}) Logs {
+	seen := map[interface{}]bool{}
+	return logs.Filter(func(log *Log) bool {
+		k := key(log)
+		if seen[k] {
+			return false
+		}
+		seen[k] = true
+		return true
+	})
+}
+
+// CountBy returns the number of logs for each key returned by key, counting
+// each log's Occurrences
+func (logs *Logs) CountBy(key func(log *Log) string) Counts {
+	counts := Counts{}
+	for i := range *logs {
+		counts[key(&(*logs)[i])] += (*logs)[i].Occurrences()
+	}
+	return counts
+}
+
+// SampleEvery returns every k-th log, starting with the first, for a
+// deterministic sample of roughly 1/k of Logs
+func (logs *Logs) SampleEvery(k int) Logs {
+	sampled := Logs{}
+	for i := 0; i < len(*logs); i += k {
+		sampled = append(sampled, (*logs)[i])
+	}
+	return sampled
+}
+
+// CollapseRepeats returns Logs where each run of consecutive identical logs
+// within a transaction is replaced by its first log, with the number of
+// collapsed logs recorded in its Extra RepeatsKey count and the time to the
+// last of them in RepeatSpanKey. Transactions keep the order in which they
+// first appear, and the logs within each are sorted by Timestamp
+func (logs *Logs) CollapseRepeats() Logs {
+	order := []string{}
+	transactions := map[string]Logs{}
 	for _, log := range *logs {
-		operations[log.Operation] = append(operations[log.Operation], log)
-	}
-	// Count the number of errors for each operation, and set it to max
-	// if greater than most errors seen thus far
-	for operation, list := range operations {
-		numErrors := 0
-		for _, log := range list {
-			if log.IsError() {
-				numErrors++
+		if _, ok := transactions[log.TransactionID]; !ok {
+			order = append(order, log.TransactionID)
+		}
+		transactions[log.TransactionID] = append(transactions[log.TransactionID], log)
+	}
+	collapsed := Logs{}
+	for _, id := range order {
+		list := transactions[id]
+		sort.Stable(list)
+		for i, log := range list {
+			last := len(collapsed) - 1
+			if i > 0 && log.IsRepeatOf(&collapsed[last]) {
+				// Copy Extra before changing it, since the map is shared with
+				// the log it was copied from
+				extra := map[string]int{}
+				for key, count := range collapsed[last].Extra {
+					extra[key] = count
+				}
+				extra[RepeatsKey] += log.Occurrences()
+				if span := log.LastTimestamp().Sub(collapsed[last].Timestamp.Time); span > time.Duration(extra[RepeatSpanKey]) {
+					extra[RepeatSpanKey] = int(span)
+				}
+				collapsed[last].Extra = extra
+				continue
 			}
+			collapsed = append(collapsed, log)
 		}
-		if numErrors > mostErrors {
-			operationWithMostErrors = operation
-			mostErrors = numErrors
+	}
+	return collapsed
+}
+
+// FirstFailingTransaction returns the ID and timestamp of the transaction
+// that logged the earliest error. Errors sharing the same timestamp are
+// ordered by transaction ID so the result is deterministic
+func (logs *Logs) FirstFailingTransaction() (string, time.Time) {
+	errorLogs := Logs{}
+	for _, log := range *logs {
+		if log.IsError() {
+			errorLogs = append(errorLogs, log)
 		}
 	}
-	return fmt.Sprintf("%s (%d Errors)", operationWithMostErrors, mostErrors)
+	if len(errorLogs) == 0 {
+		return "", time.Time{}
+	}
+	// Sort errors by Timestamp, then by TransactionID
+	sort.Slice(errorLogs, func(i, j int) bool {
+		if !errorLogs[i].Timestamp.Equal(errorLogs[j].Timestamp.Time) {
+			return errorLogs[i].Timestamp.Before(errorLogs[j].Timestamp.Time)
+		}
+		return errorLogs[i].TransactionID < errorLogs[j].TransactionID
+	})
+	first := errorLogs[0]
+	return first.TransactionID, first.Timestamp.Time
 }
 
 func main() {
-	args := os.Args[1:]
-	fileName := args[0]
-	// Read filename given by first argument
-	data, err := ioutil.ReadFile(fileName)
+	flag.DurationVar(&Interval, "interval", Interval, "bucket width of every time series")
+	collapseRepeats := flag.Bool("collapse-repeats", false, "collapse consecutive identical logs within a transaction")
+	sampleEvery := flag.Int("sample-every", 1, "keep only every k-th log, starting with the first")
+	perService := flag.Bool("per-service", false, "print the analyses separately for each service")
+	durationFrom := flag.String("duration-from", "", "measure transactions from the first log of this operation")
+	durationTo := flag.String("duration-to", "", "measure transactions to the last log of this operation")
+	output := flag.String("output", "text", "output format: text, json, otel-json, markdown or transactions")
+	sortTransactions := flag.String("sort-transactions", "start", "order of the transactions output: start, duration or count")
+	flag.IntVar(&TopN, "top-n", TopN, "number of transactions listed by longest-transactions")
+	flag.BoolVar(&AllowNegativeDurations, "allow-negative", false, "include negative transaction durations in duration metrics")
+	maxOpen := flag.Int("max-open-transactions", 0, fmt.Sprintf("stream the input, tracking at most this many open transactions (supports total and longest-transaction only). URLs are streamed with a limit of %d unless this is set, and 0 reads them in full", DefaultMaxOpenTransactions))
+	requireCoverage := flag.String("require-coverage", "", "exit with status 1 unless enough transactions touch a service, given as service:fraction")
+	flag.IntVar(&FloatPrecision, "float-precision", FloatPrecision, "decimal places to print floats with")
+	transaction := flag.String("transaction", "", "report on this transaction only")
+	excludeLevels := flag.String("exclude-levels", "", "comma-separated levels to drop before analysis")
+	dedupKey := flag.String("dedup-key", "", "drop repeated logs, matched by full or txn+ts")
+	flag.BoolVar(&Base64Input, "base64", false, "decode the input from base64 before parsing it")
+	errorTimeline := flag.Bool("error-timeline", false, "list every error in time order instead of the analyses")
+	where := flag.String("where", "", "keep only logs matching an expression, such as \"service=webserver AND level=ERROR\"")
+	boundary := flag.String("boundary-operation", "", "group logs without a transaction ID into transactions starting at this operation")
+	flag.BoolVar(&WithPercent, "with-percent", false, "print counts with their percentage of the total")
+	timezone := flag.String("tz", "UTC", "IANA time zone to read timestamps in, such as America/New_York")
+	dumpLongest := flag.Bool("dump-longest", false, "print every log of the longest transaction as JSON instead of the analyses")
+	only := flag.String("only", "", "comma-separated analyses to run (default all)")
+	rolling := flag.Duration("rolling", 0, fmt.Sprintf("while streaming, print the results so far at this interval (default %s for a URL)", DefaultRollingInterval))
+	flag.Parse()
+	if flag.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "usage: lightstep [flags] <file or URL>")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if Interval <= 0 {
+		log.Fatalf("-interval must be positive, got %s", Interval)
+	}
+	fileName := flag.Arg(0)
+	set := map[string]bool{}
+	flag.Visit(func(given *flag.Flag) {
+		set[given.Name] = true
+	})
+	// A URL is usually a stream that stays open, so it is analyzed as it
+	// arrives unless -max-open-transactions says otherwise
+	if IsURL(fileName) && !set["max-open-transactions"] {
+		*maxOpen = DefaultMaxOpenTransactions
+		if !set["rolling"] {
+			*rolling = DefaultRollingInterval
+		}
+	}
+	streaming := *maxOpen > 0
+	analyses, err := SelectAnalyses(*only)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = CheckOutput(*output, *transaction, streaming)
 	if err != nil {
 		log.Fatal(err)
 	}
-	// Parse JSON file and analyze logs
-	logs := Logs{}
-	err = json.Unmarshal(data, &logs)
+	writeReport := ReportWriters[*output]
+	err = SortTransactionSummaries(nil, *sortTransactions)
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Println("Total Log Entries:", len(logs))
-	fmt.Println("Longest Transaction:", logs.LongestTransaction())
-	fmt.Println("Operation with Most Errors:", logs.OperationWithMostErrors())
+	// Drop the logs that analyses should not see, one log at a time
+	filters := []Predicate{}
+	if *where != "" {
+		matches, err := ParseWhere(*where)
+		if err != nil {
+			log.Fatal(err)
+		}
+		filters = append(filters, matches)
+	}
+	if *excludeLevels != "" {
+		filters = append(filters, ExcludeLevels(*excludeLevels))
+	}
+	keep := All(filters...)
+	if *dedupKey != "" && DedupKeys[*dedupKey] == nil {
+		log.Fatalf("unknown dedup key %q", *dedupKey)
+	}
+	TimestampLocation, err = time.LoadLocation(*timezone)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *sampleEvery < 1 {
+		log.Fatalf("-sample-every must be at least 1, got %d", *sampleEvery)
+	}
+	if FloatPrecision < 0 {
+		log.Fatalf("-float-precision must not be negative, got %d", FloatPrecision)
+	}
+	if TopN < 1 {
+		log.Fatalf("-top-n must be at least 1, got %d", TopN)
+	}
+	coveredService := ""
+	minimumCoverage := 0.0
+	if *requireCoverage != "" {
+		coveredService, minimumCoverage, err = ParseCoverageRequirement(*requireCoverage)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *durationFrom != "" || *durationTo != "" {
+		MeasureTransaction = OperationSpan(*durationFrom, *durationTo)
+	}
+	if *rolling < 0 {
+		log.Fatalf("-rolling must not be negative, got %s", *rolling)
+	}
+	if *rolling > 0 && !streaming {
+		// Without streaming, the whole input is read before anything is analyzed
+		log.Fatal("-rolling needs a URL or -max-open-transactions")
+	}
+	if streaming {
+		for name := range set {
+			if !StreamingFlags[name] {
+				log.Fatalf("-%s is not available when streaming", name)
+			}
+		}
+		if *only == "" {
+			analyses, _ = SelectAnalyses("total,longest-transaction")
+		}
+		// Report what was read so far if interrupted with Ctrl-C
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		tracker := NewTransactionTracker(*maxOpen)
+		tracker.CoveredService = coveredService
+		stream := Stream{Analyses: analyses, Tracker: tracker, Keep: keep}
+		if *rolling > 0 {
+			stream.RollingInterval = *rolling
+			stream.Rolling = func(report Report) error {
+				return writeReport(os.Stdout, report)
+			}
+		}
+		report, err := stream.Report(ctx, fileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		warnNegative(tracker.NegativeDurations())
+		err = writeReport(os.Stdout, report)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if coveredService != "" {
+			exitUnlessCovered(coveredService, tracker.Coverage(), minimumCoverage)
+		}
+		return
+	}
+	// Read and parse filename given by first argument, then analyze logs
+	logs, err := ReadLogs(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *boundary != "" {
+		logs = logs.SegmentByBoundary(*boundary)
+	}
+	if len(filters) > 0 {
+		logs = logs.Filter(keep)
+	}
+	if *dedupKey != "" {
+		logs = logs.Dedup(DedupKeys[*dedupKey])
+	}
+	if *sampleEvery > 1 {
+		logs = logs.SampleEvery(*sampleEvery)
+	}
+	if *collapseRepeats {
+		logs = logs.CollapseRepeats()
+	}
+	warnNegative(logs.NegativeDurations())
+	if *transaction != "" {
+		report, ok := logs.TransactionReport(*transaction)
+		if !ok {
+			log.Fatalf("no logs for transaction %q", *transaction)
+		}
+		err = writeReport(os.Stdout, report)
+	} else if *dumpLongest {
+		err = DumpLongest(os.Stdout, &logs)
+	} else if *errorTimeline {
+		err = WriteLogs(os.Stdout, logs.ErrorTimeline())
+	} else if *output == TransactionsOutput {
+		summaries := logs.TransactionSummaries()
+		SortTransactionSummaries(summaries, *sortTransactions)
+		err = WriteTransactions(os.Stdout, summaries)
+	} else {
+		err = writeReport(os.Stdout, NewReport(analyses, &logs, *perService))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if coveredService != "" {
+		exitUnlessCovered(coveredService, logs.ServiceCoverage(coveredService), minimumCoverage)
+	}
+}
+
+// warnNegative logs the IDs of transactions left out of duration metrics for
+// measuring a negative duration, unless AllowNegativeDurations is set
+func warnNegative(ids []string) {
+	if len(ids) > 0 && !AllowNegativeDurations {
+		log.Printf("excluded %d transactions with negative durations: %s", len(ids), strings.Join(ids, ", "))
+	}
+}
+
+// exitUnlessCovered exits with status 1 if service covers less than minimum of
+// transactions
+func exitUnlessCovered(service string, coverage, minimum float64) {
+	if coverage < minimum {
+		log.Printf("%s covers %s of transactions, below the required %s", service, FormatFloat(coverage), FormatFloat(minimum))
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testStart is the time the logs built by newLog are written relative to
+var testStart = time.Date(2017, 10, 17, 12, 0, 0, 0, time.UTC)
+
+// newLog returns a Log of transaction id written offset after testStart
+func newLog(id, service, operation, level, message string, offset time.Duration) Log {
+	return Log{
+		Service:       service,
+		Level:         level,
+		Timestamp:     Timestamp{testStart.Add(offset)},
+		Operation:     operation,
+		Message:       message,
+		TransactionID: id,
+	}
+}
+
+func TestFirstFailingTransaction(t *testing.T) {
+	logs := Logs{
+		newLog("late", "webserver", "/login", "ERROR", "Login failed", 3*time.Second),
+		newLog("early", "webserver", "/login", "INFO", "START Logging in user", 0),
+		newLog("early", "webserver", "/login", "ERROR", "Login failed", 2*time.Second),
+		newLog("first", "db_service", "GetUser", "ERROR", "Timed out", time.Second),
+	}
+	id, at := logs.FirstFailingTransaction()
+	if id != "first" || !at.Equal(testStart.Add(time.Second)) {
+		t.Errorf("got %s at %v, want first at %v", id, at, testStart.Add(time.Second))
+	}
+}
+
+func TestFirstFailingTransactionTie(t *testing.T) {
+	logs := Logs{
+		newLog("b", "webserver", "/login", "ERROR", "Login failed", time.Second),
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", time.Second),
+	}
+	if id, _ := logs.FirstFailingTransaction(); id != "a" {
+		t.Errorf("got %s, want a", id)
+	}
+}
+
+func TestFirstFailingTransactionNoErrors(t *testing.T) {
+	logs := Logs{newLog("a", "webserver", "/login", "INFO", "START Logging in user", 0)}
+	if id, at := logs.FirstFailingTransaction(); id != "" || !at.IsZero() {
+		t.Errorf("got %s at %v, want no transaction", id, at)
+	}
+}
+
+func TestCollapseRepeatsKeepsCounts(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "ERROR", "Retrying", 0),
+		newLog("a", "webserver", "/login", "ERROR", "Retrying", time.Second),
+		newLog("a", "webserver", "/login", "ERROR", "Retrying", 2*time.Second),
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", 3*time.Second),
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", 5*time.Second),
+	}
+	collapsed := logs.CollapseRepeats()
+	if len(collapsed) != 2 {
+		t.Fatalf("got %d logs, want 2", len(collapsed))
+	}
+	if got := collapsed[0].Extra[RepeatsKey]; got != 2 {
+		t.Errorf("got %d repeats, want 2", got)
+	}
+	if got, want := collapsed.Occurrences(), len(logs); got != want {
+		t.Errorf("got %d occurrences, want %d", got, want)
+	}
+	if got, want := collapsed.ErrorRate(), logs.ErrorRate(); got != want {
+		t.Errorf("got error rate %v, want %v", got, want)
+	}
+	level := func(log *Log) string { return log.Level }
+	if got, want := collapsed.CountBy(level), logs.CountBy(level); !reflect.DeepEqual(got, want) {
+		t.Errorf("got level counts %v, want %v", got, want)
+	}
+	if message, count := collapsed.MostCommonErrorMessage(); message != "Retrying" || count != 3 {
+		t.Errorf("got %q (%d), want \"Retrying\" (3)", message, count)
+	}
+	if got, want := collapsed.OperationWithMostErrors(), logs.OperationWithMostErrors(); got != want {
+		t.Errorf("got operation with most errors %v, want %v", got, want)
+	}
+	got, _ := collapsed.CountSeries(time.Minute, (*Log).IsError)
+	if want, _ := logs.CountSeries(time.Minute, (*Log).IsError); !reflect.DeepEqual(got, want) {
+		t.Errorf("got error series %v, want %v", got, want)
+	}
+	if got, want := collapsed.LongestTransaction(), logs.LongestTransaction(); got != want {
+		t.Errorf("got longest transaction %v, want %v", got, want)
+	}
+	if logs[0].Extra != nil {
+		t.Errorf("collapsing changed the original logs")
+	}
+}
+
+func TestDecodeTraceID(t *testing.T) {
+	input := `[
+		{"service": "webserver", "level": "INFO", "timestamp": "2017-10-17 12:00:00.000000", "operation": "/login", "message": "START Logging in user", "trace_id": "a"},
+		{"service": "webserver", "level": "INFO", "timestamp": "2017-10-17 12:00:01.000000", "operation": "/login", "message": "END Logging in user", "transaction_id": "a"},
+		{"service": "webserver", "level": "INFO", "timestamp": "2017-10-17 12:00:02.000000", "operation": "/login", "message": "START Logging in user", "transaction_id": "b", "trace_id": "a"}
+	]`
+	logs := Logs{}
+	if err := json.Unmarshal([]byte(input), &logs); err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, log := range logs {
+		got = append(got, log.TransactionID)
+	}
+	if want := []string{"a", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got transactions %v, want %v", got, want)
+	}
+}
+
+func TestCollapsedRepeatsRoundTrip(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "ERROR", "Retrying", 0),
+		newLog("a", "webserver", "/login", "ERROR", "Retrying", time.Second),
+	}
+	collapsed := logs.CollapseRepeats()
+	data, err := json.Marshal(collapsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeLogs(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Occurrences(); got != 2 {
+		t.Errorf("got %d occurrences after decoding %s, want 2", got, data)
+	}
+	if got, want := decoded[0].LastTimestamp(), logs[1].Timestamp.Time; !got.Equal(want) {
+		t.Errorf("got last timestamp %v after decoding %s, want %v", got, data, want)
+	}
+}
+
+func TestSampleEvery(t *testing.T) {
+	logs := Logs{}
+	for i := 0; i < 7; i++ {
+		logs = append(logs, newLog("a", "webserver", "/login", "INFO", "Logging in user", time.Duration(i)*time.Second))
+	}
+	sampled := logs.SampleEvery(3)
+	if len(sampled) != 3 {
+		t.Fatalf("got %d logs, want 3", len(sampled))
+	}
+	for i, log := range sampled {
+		if want := testStart.Add(time.Duration(3*i) * time.Second); !log.Timestamp.Equal(want) {
+			t.Errorf("log %d written at %v, want %v", i, log.Timestamp, want)
+		}
+	}
+}
+
+func TestDedupKeys(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", 0),
+		newLog("a", "webserver", "/login", "INFO", "START  Logging in user ", 0),
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", 0),
+		newLog("b", "webserver", "/login", "INFO", "START Logging in user", 0),
+	}
+	full := logs.Dedup(DedupKeys["full"])
+	if len(full) != 3 {
+		t.Errorf("got %d logs deduplicated by full, want 3", len(full))
+	}
+	byTimestamp := logs.Dedup(DedupKeys["txn+ts"])
+	if len(byTimestamp) != 2 || byTimestamp[0].Message != "START Logging in user" {
+		t.Errorf("got %v deduplicated by txn+ts, want the first log of each transaction", byTimestamp)
+	}
+}
+
+func TestTimestampLocation(t *testing.T) {
+	defer func() { TimestampLocation = time.UTC }()
+	input := []byte(`"2017-10-17 12:00:00.000000"`)
+	instants := []time.Time{}
+	for _, name := range []string{"UTC", "America/New_York"} {
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			t.Skipf("time zone data unavailable: %v", err)
+		}
+		TimestampLocation = location
+		timestamp := Timestamp{}
+		if err := timestamp.UnmarshalJSON(input); err != nil {
+			t.Fatal(err)
+		}
+		instants = append(instants, timestamp.Time)
+	}
+	// New York is 4 hours behind UTC in October, so the same wall clock time is 4 hours later
+	if got := instants[1].Sub(instants[0]); got != 4*time.Hour {
+		t.Errorf("got %s between the two time zones, want 4h", got)
+	}
+}
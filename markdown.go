@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteMarkdown writes the Report as a Markdown document, for pasting into
+// tickets. Single values are gathered into a summary table, while lists and
+// grids get a table of their own
+func WriteMarkdown(w io.Writer, report Report) error {
+	fmt.Fprintln(w, "# Log Analysis")
+	if report.Partial {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "_Partial results: covers only the logs read so far._")
+	}
+	if report.Services == nil {
+		writeMarkdownResults(w, report.Results, "##")
+	}
+	services := []string{}
+	for service := range report.Services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		fmt.Fprintf(w, "\n## %s\n", escapeMarkdown(service))
+		writeMarkdownResults(w, report.Services[service], "###")
+	}
+	return nil
+}
+
+// writeMarkdownResults writes a summary table of single values followed by a
+// table for each list or grid, headed at the given heading level
+func writeMarkdownResults(w io.Writer, results Results, heading string) {
+	summary := [][]string{}
+	details := Results{}
+	for _, result := range results {
+		switch result.Value.(type) {
+		case OperationErrorCounts, TransactionRanking, LevelMatrix, Series:
+			details = append(details, result)
+		default:
+			summary = append(summary, []string{result.Label, formatValue(result.Value)})
+		}
+	}
+	if len(summary) > 0 {
+		fmt.Fprintf(w, "\n%s Summary\n\n", heading)
+		writeMarkdownTable(w, []string{"Analysis", "Result"}, summary)
+	}
+	for _, result := range details {
+		fmt.Fprintf(w, "\n%s %s\n\n", heading, escapeMarkdown(result.Label))
+		switch value := result.Value.(type) {
+		case OperationErrorCounts:
+			writeMarkdownTable(w, []string{"Operation", "Errors"}, operationErrorRows(value))
+		case TransactionRanking:
+			rows := [][]string{}
+			for _, transaction := range value {
+				rows = append(rows, []string{transaction.ID, transaction.Duration.String()})
+			}
+			writeMarkdownTable(w, []string{"Transaction", "Duration"}, rows)
+		case LevelMatrix:
+			header, rows := levelMatrixRows(value)
+			writeMarkdownTable(w, header, rows)
+		case Series:
+			rows := [][]string{}
+			for _, bucket := range value {
+				rows = append(rows, []string{bucket.Start.Format(TimestampLayout), strconv.Itoa(bucket.Count)})
+			}
+			writeMarkdownTable(w, []string{"Bucket", "Count"}, rows)
+		}
+	}
+}
+
+// operationErrorRows returns a row per operation, most errors first
+func operationErrorRows(counts OperationErrorCounts) [][]string {
+	operations := []string{}
+	total := 0
+	for operation, count := range counts {
+		operations = append(operations, operation)
+		total += count
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if counts[operations[i]] != counts[operations[j]] {
+			return counts[operations[i]] > counts[operations[j]]
+		}
+		return operations[i] < operations[j]
+	})
+	rows := [][]string{}
+	for _, operation := range operations {
+		rows = append(rows, []string{operation, formatCount(counts[operation], total)})
+	}
+	return rows
+}
+
+// levelMatrixRows returns a header with a column per level, and a row per service
+func levelMatrixRows(matrix LevelMatrix) ([]string, [][]string) {
+	columns := matrix.Columns()
+	rows := [][]string{}
+	for _, service := range matrix.Services() {
+		row := []string{service}
+		for _, level := range columns {
+			row = append(row, strconv.Itoa(matrix[service][level]))
+		}
+		rows = append(rows, row)
+	}
+	return append([]string{"Service"}, columns...), rows
+}
+
+// writeMarkdownTable writes a Markdown table, escaping every cell
+func writeMarkdownTable(w io.Writer, header []string, rows [][]string) {
+	writeMarkdownRow(w, header)
+	separator := []string{}
+	for range header {
+		separator = append(separator, "---")
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(separator, " | "))
+	for _, row := range rows {
+		writeMarkdownRow(w, row)
+	}
+}
+
+// writeMarkdownRow writes a single row of a Markdown table
+func writeMarkdownRow(w io.Writer, cells []string) {
+	escaped := []string{}
+	for _, cell := range cells {
+		escaped = append(escaped, escapeMarkdown(cell))
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+}
+
+// escapeMarkdown escapes pipes, which would otherwise split a table cell,
+// and replaces line breaks, which would otherwise end a table row. Backslashes
+// are escaped first, so one already before a pipe cannot cancel its escape
+func escapeMarkdown(text string) string {
+	text = strings.Replace(text, `\`, `\\`, -1)
+	text = strings.Replace(text, `|`, `\|`, -1)
+	return strings.Replace(text, "\n", " ", -1)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", `/search|all`, "ERROR", "Bad query", 0),
+		newLog("a", "webserver", `C:\|tmp`, "ERROR", "Bad path", time.Second),
+		newLog("a", "webserver", `C:\|tmp`, "ERROR", "Bad path", 2*time.Second),
+	}
+	analyses, _ := SelectAnalyses("total,operation-errors")
+	buffer := bytes.Buffer{}
+	if err := WriteMarkdown(&buffer, NewReport(analyses, &logs, false)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Log Analysis\n",
+		"## Summary\n\n| Analysis | Result |\n| --- | --- |\n| Total Log Entries | 3 |\n",
+		"## Errors by Operation\n\n| Operation | Errors |\n| --- | --- |\n",
+		`| C:\\\|tmp | 2 |` + "\n",
+		`| /search\|all | 1 |` + "\n",
+	} {
+		if !strings.Contains(buffer.String(), want) {
+			t.Errorf("got\n%s\nwant it to contain\n%s", buffer.String(), want)
+		}
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := map[string]string{
+		"a|b":    `a\|b`,
+		`a\|b`:   `a\\\|b`,
+		`a\b`:    `a\\b`,
+		"a\nb|c": `a b\|c`,
+	}
+	for text, want := range tests {
+		if got := escapeMarkdown(text); got != want {
+			t.Errorf("escapeMarkdown(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// OperationErrorCounts is the number of errors logged by each operation
+type OperationErrorCounts map[string]int
+
+// String formats OperationErrorCounts as a comma-separated list of operations
+// and their error counts, sorted by operation
+func (counts OperationErrorCounts) String() string {
+	return formatCounts(counts)
+}
+
+// ErrorCounts returns the number of errors logged by each operation that
+// logged at least one
+func (logs *Logs) ErrorCounts() OperationErrorCounts {
+	counts := OperationErrorCounts{}
+	for _, log := range *logs {
+		if log.IsError() {
+			counts[log.Operation] += log.Occurrences()
+		}
+	}
+	return counts
+}
+
+// RetryRate returns, for each operation, the fraction of transactions running
+// that operation in which it was started more than once. A high rate suggests
+// the operation is flaky and being retried
+func (logs *Logs) RetryRate() map[string]float64 {
+	transactionsWith := map[string]int{}
+	transactionsRetrying := map[string]int{}
+	for _, list := range logs.Transactions() {
+		// Count how many times each operation started in this transaction
+		starts := map[string]int{}
+		for _, log := range list {
+			if log.IsStart() {
+				starts[log.Operation] += log.Occurrences()
+			}
+		}
+		for operation, count := range starts {
+			transactionsWith[operation]++
+			if count > 1 {
+				transactionsRetrying[operation]++
+			}
+		}
+	}
+	rates := map[string]float64{}
+	for operation, count := range transactionsWith {
+		rates[operation] = float64(transactionsRetrying[operation]) / float64(count)
+	}
+	return rates
+}
+
+// OperationDurations returns the duration of every run of operation across all
+// transactions, measured from each START log to its matching END log. Logs
+// are matched within the service that wrote them, since different services
+// can run operations of the same name. A run that starts while another run of
+// the operation is open in the same service and transaction is treated as
+// nested inside it
+func (logs *Logs) OperationDurations(operation string) []time.Duration {
+	durations := []time.Duration{}
+	for _, list := range logs.Transactions() {
+		// Open runs of the operation per service, most recently started last
+		open := map[string][]time.Time{}
+		for _, log := range list {
+			if log.Operation != operation {
+				continue
+			}
+			runs := open[log.Service]
+			if log.IsStart() {
+				open[log.Service] = append(runs, log.Timestamp.Time)
+			} else if log.IsEnd() && len(runs) > 0 {
+				started := runs[len(runs)-1]
+				open[log.Service] = runs[:len(runs)-1]
+				durations = append(durations, log.Timestamp.Sub(started))
+			}
+		}
+	}
+	return durations
+}
+
+// OperationPercentileLatency returns the p-th percentile (between 0 and 100)
+// of the durations of operation, using the nearest-rank method, or 0 if the
+// operation never completed.
+// See: https://en.wikipedia.org/wiki/Percentile#The_nearest-rank_method
+func (logs *Logs) OperationPercentileLatency(operation string, p float64) time.Duration {
+	durations := logs.OperationDurations(operation)
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+	rank := int(math.Ceil(p / 100 * float64(len(durations))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(durations) {
+		rank = len(durations)
+	}
+	return durations[rank-1]
+}
+
+// OperationErrorRates returns the fraction of each operation's logs that are errors
+func (logs *Logs) OperationErrorRates() map[string]float64 {
+	totals := map[string]int{}
+	for _, log := range *logs {
+		totals[log.Operation] += log.Occurrences()
+	}
+	errorCounts := logs.ErrorCounts()
+	rates := map[string]float64{}
+	for operation, total := range totals {
+		rates[operation] = float64(errorCounts[operation]) / float64(total)
+	}
+	return rates
+}
+
+// OperationsWithErrorRegression returns the operations, sorted, whose error rate
+// in the second half of the time range covered by Logs is at least minIncrease
+// higher than in the first half. Operations that did not log in both halves
+// are left out, since there is nothing to compare
+func (logs *Logs) OperationsWithErrorRegression(minIncrease float64) []string {
+	earliest, latest := logs.TimeRange()
+	firstHalf, secondHalf := logs.SplitAt(earliest.Add(latest.Sub(earliest) / 2))
+	before := firstHalf.OperationErrorRates()
+	after := secondHalf.OperationErrorRates()
+	regressed := []string{}
+	for operation, rate := range after {
+		previous, ok := before[operation]
+		if ok && rate-previous >= minIncrease {
+			regressed = append(regressed, operation)
+		}
+	}
+	sort.Strings(regressed)
+	return regressed
+}
+
+// ErrorConcentrationGini returns the Gini coefficient of error counts across
+// every operation in Logs, including those with no errors. A value near 0
+// means errors are spread evenly across operations, while a value near 1
+// means one operation dominates (with n operations the maximum is (n-1)/n).
+// With the error counts x sorted in ascending order and numbered from 1 to n:
+//
+//	G = 2 * sum(i * x[i]) / (n * sum(x[i])) - (n + 1) / n
+//
+// See: https://en.wikipedia.org/wiki/Gini_coefficient#Alternative_expressions
+// Returns 0 when there are no errors
+func (logs *Logs) ErrorConcentrationGini() float64 {
+	errorCounts := logs.ErrorCounts()
+	counts := []int{}
+	for operation := range logs.CountBy(func(log *Log) string { return log.Operation }) {
+		counts = append(counts, errorCounts[operation])
+	}
+	sort.Ints(counts)
+	total := 0
+	weighted := 0
+	for i, count := range counts {
+		total += count
+		weighted += (i + 1) * count
+	}
+	if total == 0 {
+		return 0
+	}
+	n := float64(len(counts))
+	return 2*float64(weighted)/(n*float64(total)) - (n+1)/n
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRetryRate(t *testing.T) {
+	logs := Logs{}
+	for i, id := range []string{"a", "b", "c", "d"} {
+		offset := time.Duration(i) * time.Minute
+		logs = append(logs,
+			newLog(id, "loadbalancer", "GET", "INFO", "START /index requested", offset),
+			newLog(id, "db_service", "GetUser", "INFO", "START Retrieving user information", offset+time.Second),
+		)
+		// GetUser is retried in all but the last transaction
+		if id != "d" {
+			logs = append(logs, newLog(id, "db_service", "GetUser", "INFO", "START Retrieving user information", offset+2*time.Second))
+		}
+	}
+	rates := logs.RetryRate()
+	if rates["GetUser"] != 0.75 {
+		t.Errorf("got GetUser retry rate %v, want 0.75", rates["GetUser"])
+	}
+	if rates["GET"] != 0 {
+		t.Errorf("got GET retry rate %v, want 0", rates["GET"])
+	}
+}
+
+func TestOperationDurationsPerService(t *testing.T) {
+	// Both services run GetAccount, overlapping within one transaction
+	logs := Logs{
+		newLog("a", "account_service", "GetAccount", "INFO", "START Retrieving user account information", 0),
+		newLog("a", "db_service", "GetAccount", "INFO", "START Retrieving account information", time.Second),
+		newLog("a", "account_service", "GetAccount", "INFO", "END Retrieving user account information", 4*time.Second),
+		newLog("a", "db_service", "GetAccount", "INFO", "END Retrieving account information", 6*time.Second),
+	}
+	got := logs.OperationDurations("GetAccount")
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	if want := []time.Duration{4 * time.Second, 5 * time.Second}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOperationPercentileLatency(t *testing.T) {
+	logs := Logs{}
+	for i := 1; i <= 20; i++ {
+		id := string(rune('a' + i))
+		logs = append(logs,
+			newLog(id, "db_service", "GetUser", "INFO", "START Retrieving user information", 0),
+			newLog(id, "db_service", "GetUser", "INFO", "END Retrieving user information", time.Duration(i)*time.Second),
+		)
+	}
+	if got := logs.OperationPercentileLatency("GetUser", 95); got != 19*time.Second {
+		t.Errorf("got p95 %s, want 19s", got)
+	}
+	if got := logs.OperationPercentileLatency("GetUser", 100); got != 20*time.Second {
+		t.Errorf("got p100 %s, want 20s", got)
+	}
+	if got := logs.OperationPercentileLatency("GetAccount", 95); got != 0 {
+		t.Errorf("got p95 %s for an operation that never ran, want 0s", got)
+	}
+}
+
+func TestOperationsWithErrorRegression(t *testing.T) {
+	logs := Logs{}
+	for i := 0; i < 8; i++ {
+		offset := time.Duration(i) * time.Minute
+		// GetUser only fails in the second half, GET fails half the time throughout
+		getUserLevel, getLevel := "INFO", "INFO"
+		if i >= 4 {
+			getUserLevel = ErrorLevel
+		}
+		if i%2 == 0 {
+			getLevel = ErrorLevel
+		}
+		logs = append(logs,
+			newLog("a", "db_service", "GetUser", getUserLevel, "Retrieving user information", offset),
+			newLog("a", "loadbalancer", "GET", getLevel, "/index requested", offset),
+		)
+	}
+	got := logs.OperationsWithErrorRegression(0.5)
+	if want := []string{"GetUser"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestErrorConcentrationGini(t *testing.T) {
+	// errorLogs returns count error logs of operation
+	errorLogs := func(operation string, count int) Logs {
+		logs := Logs{}
+		for i := 0; i < count; i++ {
+			logs = append(logs, newLog("a", "webserver", operation, ErrorLevel, "Failed", time.Duration(i)*time.Second))
+		}
+		return logs
+	}
+	concentrated := errorLogs("GET", 97)
+	for _, operation := range []string{"POST", "GetUser", "GetAccount"} {
+		concentrated = append(concentrated, errorLogs(operation, 1)...)
+	}
+	if got := concentrated.ErrorConcentrationGini(); got < 0.7 {
+		t.Errorf("got %v for concentrated errors, want close to the maximum of 0.75", got)
+	}
+	even := Logs{}
+	for _, operation := range []string{"GET", "POST", "GetUser", "GetAccount"} {
+		even = append(even, errorLogs(operation, 25)...)
+	}
+	if got := even.ErrorConcentrationGini(); math.Abs(got) > 1e-9 {
+		t.Errorf("got %v for even errors, want 0", got)
+	}
+	none := Logs{}
+	if got := none.ErrorConcentrationGini(); got != 0 {
+		t.Errorf("got %v without errors, want 0", got)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The types below mirror the parts of the OTLP/JSON metrics encoding that
+// the otel-json output uses, so it can be sent to an OpenTelemetry collector
+// without depending on the SDK.
+// See: https://github.com/open-telemetry/opentelemetry-proto/blob/main/examples/metrics.json
+
+// OTelMetricsData is the top level of an OTLP/JSON metrics document
+type OTelMetricsData struct {
+	ResourceMetrics []OTelResourceMetrics `json:"resourceMetrics"`
+}
+
+// OTelResourceMetrics holds the metrics describing a single resource
+type OTelResourceMetrics struct {
+	Resource     OTelResource       `json:"resource"`
+	ScopeMetrics []OTelScopeMetrics `json:"scopeMetrics"`
+}
+
+// OTelResource describes the entity the metrics were measured for
+type OTelResource struct {
+	Attributes []OTelAttribute `json:"attributes"`
+}
+
+// OTelScopeMetrics holds the metrics produced by a single instrumentation scope
+type OTelScopeMetrics struct {
+	Scope   OTelScope    `json:"scope"`
+	Metrics []OTelMetric `json:"metrics"`
+}
+
+// OTelScope names the instrumentation scope that produced the metrics
+type OTelScope struct {
+	Name string `json:"name"`
+}
+
+// OTelMetric is a single named metric, encoded as either a gauge or a sum
+type OTelMetric struct {
+	Name  string     `json:"name"`
+	Unit  string     `json:"unit,omitempty"`
+	Gauge *OTelGauge `json:"gauge,omitempty"`
+	Sum   *OTelSum   `json:"sum,omitempty"`
+}
+
+// OTelGauge holds data points measured at a single moment
+type OTelGauge struct {
+	DataPoints []OTelDataPoint `json:"dataPoints"`
+}
+
+// OTelSum holds data points that add up over time
+type OTelSum struct {
+	DataPoints             []OTelDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+// OTelDataPoint is a single value of a metric. OTLP/JSON encodes integer
+// values as strings
+type OTelDataPoint struct {
+	Attributes []OTelAttribute `json:"attributes,omitempty"`
+	AsInt      string          `json:"asInt,omitempty"`
+	AsDouble   *float64        `json:"asDouble,omitempty"`
+}
+
+// OTelAttribute is a key and string value describing a resource or data point
+type OTelAttribute struct {
+	Key   string       `json:"key"`
+	Value OTelAnyValue `json:"value"`
+}
+
+// OTelAnyValue is the value of an OTelAttribute
+type OTelAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// OTelScopeName names this program as the instrumentation scope of its metrics
+const OTelScopeName = "lightstep-challenge"
+
+// OTelCumulative is the OTLP aggregation temporality for sums counted from the
+// start of the input
+const OTelCumulative = 2
+
+// WriteOTelJSON writes the numeric Results of the Report as OTLP/JSON metrics.
+// Per-service reports produce one resource per service, identified by its
+// service.name attribute. Results with no numeric value are left out
+func WriteOTelJSON(w io.Writer, report Report) error {
+	data := OTelMetricsData{ResourceMetrics: []OTelResourceMetrics{}}
+	if report.Services == nil {
+		data.ResourceMetrics = append(data.ResourceMetrics, newOTelResourceMetrics(nil, report.Results))
+	}
+	services := []string{}
+	for service := range report.Services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		attributes := []OTelAttribute{newOTelAttribute("service.name", service)}
+		data.ResourceMetrics = append(data.ResourceMetrics, newOTelResourceMetrics(attributes, report.Services[service]))
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// newOTelResourceMetrics converts Results into the metrics of a single resource
+func newOTelResourceMetrics(attributes []OTelAttribute, results Results) OTelResourceMetrics {
+	metrics := []OTelMetric{}
+	for _, result := range results {
+		metric, ok := newOTelMetric(result)
+		if ok {
+			metrics = append(metrics, metric)
+		}
+	}
+	if attributes == nil {
+		attributes = []OTelAttribute{}
+	}
+	return OTelResourceMetrics{
+		Resource: OTelResource{Attributes: attributes},
+		ScopeMetrics: []OTelScopeMetrics{{
+			Scope:   OTelScope{Name: OTelScopeName},
+			Metrics: metrics,
+		}},
+	}
+}
+
+// newOTelMetric converts a Result into a metric named after its analysis,
+// returning false if the Result has no numeric value
+func newOTelMetric(result Result) (OTelMetric, bool) {
+	metric := OTelMetric{Name: "lightstep." + strings.Replace(result.Name, "-", "_", -1)}
+	switch value := result.Value.(type) {
+	case int:
+		metric.Gauge = &OTelGauge{DataPoints: []OTelDataPoint{newOTelIntDataPoint(nil, value)}}
+	case float64:
+		metric.Gauge = &OTelGauge{DataPoints: []OTelDataPoint{{AsDouble: &value}}}
+	case time.Duration:
+		seconds := value.Seconds()
+		metric.Unit = "s"
+		metric.Gauge = &OTelGauge{DataPoints: []OTelDataPoint{{AsDouble: &seconds}}}
+	case OperationErrorCounts:
+		operations := []string{}
+		for operation := range value {
+			operations = append(operations, operation)
+		}
+		sort.Strings(operations)
+		points := []OTelDataPoint{}
+		for _, operation := range operations {
+			attributes := []OTelAttribute{newOTelAttribute("operation", operation)}
+			points = append(points, newOTelIntDataPoint(attributes, value[operation]))
+		}
+		metric.Sum = &OTelSum{DataPoints: points, AggregationTemporality: OTelCumulative, IsMonotonic: true}
+	default:
+		return OTelMetric{}, false
+	}
+	return metric, true
+}
+
+// newOTelIntDataPoint returns a data point holding an integer value
+func newOTelIntDataPoint(attributes []OTelAttribute, value int) OTelDataPoint {
+	return OTelDataPoint{Attributes: attributes, AsInt: strconv.Itoa(value)}
+}
+
+// newOTelAttribute returns an attribute with a string value
+func newOTelAttribute(key, value string) OTelAttribute {
+	return OTelAttribute{Key: key, Value: OTelAnyValue{StringValue: value}}
+}
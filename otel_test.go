@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteOTelJSONErrorCounts(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", 0),
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", time.Second),
+		newLog("a", "db_service", "GetUser", "ERROR", "Timed out", 2*time.Second),
+		newLog("a", "loadbalancer", "GET", "INFO", "END /index requested", 3*time.Second),
+	}
+	analyses, _ := SelectAnalyses("operation-errors")
+	buffer := bytes.Buffer{}
+	if err := WriteOTelJSON(&buffer, NewReport(analyses, &logs, false)); err != nil {
+		t.Fatal(err)
+	}
+	data := OTelMetricsData{}
+	if err := json.Unmarshal(buffer.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.ResourceMetrics) != 1 || len(data.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("got %s, want a single resource and scope", buffer.String())
+	}
+	scope := data.ResourceMetrics[0].ScopeMetrics[0]
+	if scope.Scope.Name != OTelScopeName || len(scope.Metrics) != 1 {
+		t.Fatalf("got scope %q with %d metrics, want %q with 1", scope.Scope.Name, len(scope.Metrics), OTelScopeName)
+	}
+	metric := scope.Metrics[0]
+	if metric.Name != "lightstep.operation_errors" || metric.Sum == nil || metric.Gauge != nil {
+		t.Fatalf("got metric %+v, want a sum named lightstep.operation_errors", metric)
+	}
+	if metric.Sum.AggregationTemporality != OTelCumulative || !metric.Sum.IsMonotonic {
+		t.Errorf("got temporality %d and monotonic %v, want a cumulative monotonic sum", metric.Sum.AggregationTemporality, metric.Sum.IsMonotonic)
+	}
+	want := []OTelDataPoint{
+		{Attributes: []OTelAttribute{newOTelAttribute("operation", "/login")}, AsInt: "2"},
+		{Attributes: []OTelAttribute{newOTelAttribute("operation", "GetUser")}, AsInt: "1"},
+	}
+	if !reflect.DeepEqual(metric.Sum.DataPoints, want) {
+		t.Errorf("got data points %+v, want %+v", metric.Sum.DataPoints, want)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// SchemaVersion is the version of the JSON output shape. Bump it whenever a
+// change to Report would break existing consumers
+const SchemaVersion = 1
+
+// MarshalJSON defines the interface for marshalling Results into a JSON object
+// whose keys keep the order the Analyses were run in
+func (results Results) MarshalJSON() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	buffer.WriteString("{")
+	for i, result := range results {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		name, err := json.Marshal(result.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(result.Value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(name)
+		buffer.WriteString(":")
+		buffer.Write(value)
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+// Report holds the Results for all Logs, or for each service separately.
+// A Partial Report covers only the logs read so far, because the input was
+// interrupted or is still being read
+type Report struct {
+	SchemaVersion int                `json:"schema_version"`
+	Partial       bool               `json:"partial,omitempty"`
+	Results       Results            `json:"results,omitempty"`
+	Services      map[string]Results `json:"services,omitempty"`
+}
+
+// NewReport runs the analyses over logs, split by service if perService is set
+func NewReport(analyses []Analysis, logs *Logs, perService bool) Report {
+	report := Report{SchemaVersion: SchemaVersion}
+	if !perService {
+		report.Results = RunAnalyses(analyses, logs)
+		return report
+	}
+	report.Services = map[string]Results{}
+	for _, service := range logs.Services() {
+		serviceLogs := logs.Filter(ByService(service))
+		report.Services[service] = RunAnalyses(analyses, &serviceLogs)
+	}
+	return report
+}
+
+// ReportWriter writes a Report in a particular output format
+type ReportWriter func(w io.Writer, report Report) error
+
+// ReportWriters are the output formats available on the command line
+var ReportWriters = map[string]ReportWriter{
+	"text":      WriteText,
+	"json":      WriteJSON,
+	"otel-json": WriteOTelJSON,
+	"markdown":  WriteMarkdown,
+}
+
+// WriteText writes one labelled line per Result, with a heading for each service
+func WriteText(w io.Writer, report Report) error {
+	if report.Partial {
+		fmt.Fprintln(w, "Partial results: covers only the logs read so far")
+	}
+	writeResults(w, report.Results)
+	services := []string{}
+	for service := range report.Services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for i, service := range services {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "[%s]\n", service)
+		writeResults(w, report.Services[service])
+	}
+	return nil
+}
+
+// FloatPrecision is the number of decimal places floats are printed with
+var FloatPrecision = 2
+
+// FormatFloat formats value with FloatPrecision decimal places
+func FormatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', FloatPrecision, 64)
+}
+
+// WithPercent prints counts along with their percentage of the total
+var WithPercent = false
+
+// Counts is the number of logs per key, such as per level or per service
+type Counts map[string]int
+
+// String formats Counts as a comma-separated list of keys and their counts,
+// sorted by key
+func (counts Counts) String() string {
+	return formatCounts(counts)
+}
+
+// formatCounts formats a comma-separated list of keys and their counts,
+// sorted by key, adding each count's percentage of the total if WithPercent
+// is set
+func formatCounts(counts map[string]int) string {
+	keys := []string{}
+	total := 0
+	for key, count := range counts {
+		keys = append(keys, key)
+		total += count
+	}
+	sort.Strings(keys)
+	formatted := []string{}
+	for _, key := range keys {
+		formatted = append(formatted, fmt.Sprintf("%s: %s", key, formatCount(counts[key], total)))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// formatCount formats count, followed by its percentage of total if
+// WithPercent is set
+func formatCount(count, total int) string {
+	if !WithPercent || total == 0 {
+		return strconv.Itoa(count)
+	}
+	return fmt.Sprintf("%d (%s%%)", count, FormatFloat(100*float64(count)/float64(total)))
+}
+
+// formatValue formats the value of a Result for text output
+func formatValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "none"
+	case float64:
+		return FormatFloat(value)
+	case time.Time:
+		return value.Format(TimestampLayout)
+	}
+	return fmt.Sprint(value)
+}
+
+// writeResults writes one labelled line per Result. Results that format
+// across several lines, such as grids, start on the line after their label
+func writeResults(w io.Writer, results Results) {
+	for _, result := range results {
+		value := formatValue(result.Value)
+		if strings.Contains(value, "\n") {
+			fmt.Fprintf(w, "%s:\n%s", result.Label, value)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s\n", result.Label, value)
+	}
+}
+
+// WriteJSON writes the Report as an indented JSON document
+func WriteJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// TransactionsOutput is the output format that exports a TransactionSummary per
+// transaction as newline-delimited JSON, instead of writing a Report
+const TransactionsOutput = "transactions"
+
+// CheckOutput returns an error unless output is one of ReportWriters or
+// TransactionsOutput. TransactionsOutput exports every transaction, so it
+// cannot be combined with a report on a single transaction or with streaming
+func CheckOutput(output, transaction string, streaming bool) error {
+	if output != TransactionsOutput {
+		if ReportWriters[output] == nil {
+			return fmt.Errorf("unknown output format %q", output)
+		}
+		return nil
+	}
+	if transaction != "" {
+		return fmt.Errorf("-output=%s is not available with -transaction", TransactionsOutput)
+	}
+	if streaming {
+		return fmt.Errorf("-output=%s is not available when streaming", TransactionsOutput)
+	}
+	return nil
+}
+
+// WriteTransactions writes each TransactionSummary as a line of JSON, along
+// with the SchemaVersion of its shape, since each line stands on its own
+func WriteTransactions(w io.Writer, summaries []TransactionSummary) error {
+	encoder := json.NewEncoder(w)
+	for _, summary := range summaries {
+		err := encoder.Encode(struct {
+			SchemaVersion int `json:"schema_version"`
+			TransactionSummary
+		}{SchemaVersion, summary})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteLogs writes a line per log, with its fields aligned in columns. A log
+// standing for collapsed repeats has its Occurrences appended to its message
+func WriteLogs(w io.Writer, logs Logs) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, log := range logs {
+		message := log.Message
+		if log.Occurrences() > 1 {
+			message = fmt.Sprintf("%s (x%d)", message, log.Occurrences())
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			log.Timestamp, log.Level, log.Service, log.Operation, log.TransactionID, message)
+	}
+	return writer.Flush()
+}
+
+// WriteLogsJSON writes logs as an indented JSON list, in the format they are read in.
+// This is a raw export that can be read back as input, not a versioned Report,
+// so it has no SchemaVersion
+func WriteLogsJSON(w io.Writer, logs Logs) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(logs)
+}
+
+// DumpLongest writes every log of the longest transaction, in time order,
+// with WriteLogsJSON
+func DumpLongest(w io.Writer, logs *Logs) error {
+	longest := logs.TopLongestTransactions(1)
+	if len(longest) == 0 {
+		return fmt.Errorf("no transactions to dump")
+	}
+	return WriteLogsJSON(w, logs.Transaction(longest[0].ID))
+}
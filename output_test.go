@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewReportPerService(t *testing.T) {
+	logs := Logs{
+		newLog("a", "loadbalancer", "GET", "INFO", "START /index requested", 0),
+		newLog("a", "webserver", "/index", "ERROR", "Unauthenticated request", time.Second),
+		newLog("a", "loadbalancer", "GET", "INFO", "END /index requested", 2*time.Second),
+	}
+	if got, want := logs.Services(), []string{"loadbalancer", "webserver"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got services %v, want %v", got, want)
+	}
+	analyses, _ := SelectAnalyses("total,error-count")
+	report := NewReport(analyses, &logs, true)
+	if report.Results != nil || len(report.Services) != 2 {
+		t.Fatalf("got results %v and %d services, want only 2 services", report.Results, len(report.Services))
+	}
+	buffer := bytes.Buffer{}
+	WriteText(&buffer, report)
+	want := "[loadbalancer]\nTotal Log Entries: 2\nOperation with Most Errors:  (0 Errors)\n\n[webserver]\nTotal Log Entries: 1\nOperation with Most Errors: /index (1 Errors)\n"
+	if buffer.String() != want {
+		t.Errorf("got %q, want %q", buffer.String(), want)
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	logs := transaction("a", 0, time.Second)
+	logs = append(logs, transaction("b", time.Second, time.Second)...)
+	buffer := bytes.Buffer{}
+	WriteJSON(&buffer, NewReport(Analyses, &logs, false))
+	report := struct {
+		SchemaVersion int `json:"schema_version"`
+	}{}
+	if err := json.Unmarshal(buffer.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.SchemaVersion != SchemaVersion {
+		t.Errorf("got schema_version %d in the report, want %d", report.SchemaVersion, SchemaVersion)
+	}
+
+	buffer.Reset()
+	WriteTransactions(&buffer, logs.TransactionSummaries())
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for _, line := range lines {
+		summary := struct {
+			SchemaVersion int    `json:"schema_version"`
+			ID            string `json:"id"`
+		}{}
+		if err := json.Unmarshal([]byte(line), &summary); err != nil {
+			t.Fatal(err)
+		}
+		if summary.SchemaVersion != SchemaVersion || summary.ID == "" {
+			t.Errorf("got %s, want a transaction with schema_version %d", line, SchemaVersion)
+		}
+	}
+}
+
+func TestWriteTransactionsUnmeasured(t *testing.T) {
+	logs := transaction("measured", 0, time.Second)
+	// Logs its END before its START, so it is left out of duration metrics
+	logs = append(logs, transaction("skewed", 2*time.Second, -time.Second)...)
+	buffer := bytes.Buffer{}
+	WriteTransactions(&buffer, logs.TransactionSummaries())
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if !strings.Contains(lines[0], `"duration_ns":1000000000`) {
+		t.Errorf("got %s, want a duration of 1s", lines[0])
+	}
+	if strings.Contains(lines[1], "duration_ns") {
+		t.Errorf("got %s, want no duration for an excluded transaction", lines[1])
+	}
+}
+
+func TestFloatPrecision(t *testing.T) {
+	defer func() { FloatPrecision = 2 }()
+	logs := Logs{
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", 0),
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", time.Second),
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", 2*time.Second),
+	}
+	analyses, _ := SelectAnalyses("error-rate")
+	for precision, want := range map[int]string{0: "0", 2: "0.33", 4: "0.3333"} {
+		FloatPrecision = precision
+		buffer := bytes.Buffer{}
+		WriteText(&buffer, NewReport(analyses, &logs, false))
+		if got := buffer.String(); got != "Error Rate: "+want+"\n" {
+			t.Errorf("got %q with precision %d, want rate %s", got, precision, want)
+		}
+	}
+}
+
+func TestCheckOutput(t *testing.T) {
+	tests := []struct {
+		output, transaction string
+		streaming, ok       bool
+	}{
+		{"json", "a", false, true},
+		{"json", "", true, true},
+		{TransactionsOutput, "", false, true},
+		{TransactionsOutput, "a", false, false},
+		{TransactionsOutput, "", true, false},
+		{"yaml", "", false, false},
+	}
+	for _, test := range tests {
+		err := CheckOutput(test.output, test.transaction, test.streaming)
+		if (err == nil) != test.ok {
+			t.Errorf("got %v for -output=%s -transaction=%q streaming %v", err, test.output, test.transaction, test.streaming)
+		}
+	}
+}
+
+func TestWithPercent(t *testing.T) {
+	defer func() { WithPercent = false }()
+	WithPercent = true
+	logs := Logs{
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", 0),
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", time.Second),
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", 2*time.Second),
+		newLog("a", "webserver", "/login", "INFO", "Logged in", 3*time.Second),
+	}
+	analyses, _ := SelectAnalyses("level-counts")
+	buffer := bytes.Buffer{}
+	WriteText(&buffer, NewReport(analyses, &logs, false))
+	if want := "Logs by Level: ERROR: 1 (25.00%), INFO: 3 (75.00%)\n"; buffer.String() != want {
+		t.Errorf("got %q, want %q", buffer.String(), want)
+	}
+}
+
+func TestDumpLongest(t *testing.T) {
+	logs := Logs{
+		newLog("long", "loadbalancer", "GET", "INFO", "END /index requested", 3*time.Second),
+		newLog("short", "loadbalancer", "GET", "INFO", "START /index requested", 0),
+		newLog("long", "webserver", "/index", "INFO", "Rendering page", time.Second),
+		newLog("long", "loadbalancer", "GET", "INFO", "START /index requested", 0),
+		newLog("short", "loadbalancer", "GET", "INFO", "END /index requested", time.Second),
+	}
+	buffer := bytes.Buffer{}
+	if err := DumpLongest(&buffer, &logs); err != nil {
+		t.Fatal(err)
+	}
+	dumped, err := DecodeLogs(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Logs{logs[3], logs[2], logs[0]}
+	if !reflect.DeepEqual(dumped, want) {
+		t.Errorf("got %v, want %v", dumped, want)
+	}
+	empty := Logs{}
+	if err := DumpLongest(&buffer, &empty); err == nil {
+		t.Error("got no error dumping without transactions")
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Interval is the bucket width of every time-series output, so the series
+// they produce line up on the same buckets
+var Interval = time.Minute
+
+// MaxSeriesBuckets caps the number of buckets a series may contain, so a tiny
+// bucket over a long time range cannot exhaust memory
+const MaxSeriesBuckets = 100000
+
+// CountBucket is the number of logs written within a bucket starting at Start
+type CountBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// Series is a list of CountBuckets in time order
+type Series []CountBucket
+
+// String formats Series as its number of buckets and its busiest bucket, since
+// a line per bucket would crowd out every other result
+func (series Series) String() string {
+	if len(series) == 0 {
+		return "no buckets"
+	}
+	peak := series[0]
+	for _, bucket := range series {
+		if bucket.Count > peak.Count {
+			peak = bucket
+		}
+	}
+	return fmt.Sprintf("%d buckets from %s, peak of %d at %s",
+		len(series), series[0].Start.Format(TimestampLayout), peak.Count, peak.Start.Format(TimestampLayout))
+}
+
+// CountSeries returns the number of logs matching keep per bucket across the
+// full time range covered by Logs, with empty buckets included as zero counts
+// so gaps are visible. Buckets are bucket wide and aligned with time.Truncate,
+// so series of the same Logs share bucket boundaries. A collapsed log is
+// counted in the bucket of its Timestamp
+func (logs *Logs) CountSeries(bucket time.Duration, keep Predicate) (Series, error) {
+	if bucket <= 0 {
+		return nil, fmt.Errorf("bucket must be positive, got %s", bucket)
+	}
+	if len(*logs) == 0 {
+		return Series{}, nil
+	}
+	earliest, latest := logs.TimeRange()
+	first := earliest.Truncate(bucket)
+	numBuckets := int64(latest.Truncate(bucket).Sub(first)/bucket) + 1
+	if numBuckets > MaxSeriesBuckets {
+		return nil, fmt.Errorf("%d buckets of %s exceeds the limit of %d", numBuckets, bucket, MaxSeriesBuckets)
+	}
+	series := make(Series, numBuckets)
+	for i := range series {
+		series[i].Start = first.Add(time.Duration(i) * bucket)
+	}
+	for i := range *logs {
+		log := &(*logs)[i]
+		if keep(log) {
+			index := log.Timestamp.Truncate(bucket).Sub(first) / bucket
+			series[index].Count += log.Occurrences()
+		}
+	}
+	return series, nil
+}
+
+// DenseCountSeries returns the number of logs per bucket like CountSeries,
+// counting every log
+func (logs *Logs) DenseCountSeries(bucket time.Duration) (Series, error) {
+	return logs.CountSeries(bucket, func(log *Log) bool { return true })
+}
+
+// RecentErrorConcentration returns the fraction of all errors that were logged
+// in the final bucket of the file, where buckets are bucket wide and aligned
+// with time.Truncate. A value close to 1 means errors are piling up at the end.
+// Returns 0 when there are no errors
+func (logs *Logs) RecentErrorConcentration(bucket time.Duration) float64 {
+	_, latest := logs.TimeRange()
+	finalBucket := latest.Truncate(bucket)
+	totalErrors := 0
+	recentErrors := 0
+	for _, log := range *logs {
+		if !log.IsError() {
+			continue
+		}
+		totalErrors += log.Occurrences()
+		if log.Timestamp.Truncate(bucket).Equal(finalBucket) {
+			recentErrors += log.Occurrences()
+		}
+	}
+	if totalErrors == 0 {
+		return 0
+	}
+	return float64(recentErrors) / float64(totalErrors)
+}
+
+// seriesResult returns series to print, or the message of err if there is too
+// much of it to print
+func seriesResult(series Series, err error) interface{} {
+	if err != nil {
+		return err.Error()
+	}
+	return series
+}
+
+// VolumeErrorCorrelation returns the Pearson correlation between the number of
+// logs in each bucket and the error rate of that bucket, showing whether errors
+// rise with load. Only buckets containing logs are compared. Returns NaN when
+// the correlation is undefined: fewer than two buckets, or a volume or error
+// rate that never changes
+func (logs *Logs) VolumeErrorCorrelation(bucket time.Duration) float64 {
+	volumes := map[time.Time]float64{}
+	errorCounts := map[time.Time]float64{}
+	for _, log := range *logs {
+		start := log.Timestamp.Truncate(bucket)
+		volumes[start] += float64(log.Occurrences())
+		if log.IsError() {
+			errorCounts[start] += float64(log.Occurrences())
+		}
+	}
+	if len(volumes) < 2 {
+		return math.NaN()
+	}
+	// Pearson correlation, see: https://en.wikipedia.org/wiki/Pearson_correlation_coefficient
+	n := float64(len(volumes))
+	var meanVolume, meanRate float64
+	for start, volume := range volumes {
+		meanVolume += volume / n
+		meanRate += errorCounts[start] / volume / n
+	}
+	var covariance, volumeVariance, rateVariance float64
+	for start, volume := range volumes {
+		volumeDelta := volume - meanVolume
+		rateDelta := errorCounts[start]/volume - meanRate
+		covariance += volumeDelta * rateDelta
+		volumeVariance += volumeDelta * volumeDelta
+		rateVariance += rateDelta * rateDelta
+	}
+	if volumeVariance == 0 || rateVariance == 0 {
+		return math.NaN()
+	}
+	return covariance / math.Sqrt(volumeVariance*rateVariance)
+}
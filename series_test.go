@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSeriesShareBucketBoundaries(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", 10*time.Second),
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", 75*time.Second),
+		newLog("b", "webserver", "/login", "INFO", "START Logging in user", 130*time.Second),
+		newLog("b", "webserver", "/login", "ERROR", "Login failed", 190*time.Second),
+	}
+	volume, err := logs.DenseCountSeries(30 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errorSeries, err := logs.CountSeries(30*time.Second, (*Log).IsError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(volume) != 7 || len(errorSeries) != len(volume) {
+		t.Fatalf("got %d and %d buckets, want 7 each", len(volume), len(errorSeries))
+	}
+	for i := range volume {
+		if !volume[i].Start.Equal(errorSeries[i].Start) {
+			t.Errorf("bucket %d starts at %v and %v", i, volume[i].Start, errorSeries[i].Start)
+		}
+	}
+	if errorSeries[0].Count != 0 || errorSeries[2].Count != 1 {
+		t.Errorf("got error series %v, want errors in the third and last buckets", errorSeries)
+	}
+}
+
+func TestRecentErrorConcentration(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", 0),
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", 30*time.Second),
+		newLog("b", "webserver", "/login", "ERROR", "Login failed", 9*time.Minute+10*time.Second),
+		newLog("b", "webserver", "/login", "ERROR", "Login failed", 9*time.Minute+20*time.Second),
+		newLog("b", "webserver", "/login", "ERROR", "Login failed", 9*time.Minute+30*time.Second),
+	}
+	if got := logs.RecentErrorConcentration(time.Minute); got != 0.75 {
+		t.Errorf("got %v, want 0.75", got)
+	}
+	noErrors := logs[1:2]
+	if got := noErrors.RecentErrorConcentration(time.Minute); got != 0 {
+		t.Errorf("got %v without errors, want 0", got)
+	}
+}
+
+func TestDenseCountSeriesFillsGaps(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", 10*time.Second),
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", 20*time.Second),
+		newLog("b", "webserver", "/login", "INFO", "START Logging in user", 3*time.Minute+5*time.Second),
+	}
+	series, err := logs.DenseCountSeries(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{2, 0, 0, 1}
+	if len(series) != len(want) {
+		t.Fatalf("got %d buckets, want %d", len(series), len(want))
+	}
+	for i, bucket := range series {
+		if bucket.Count != want[i] {
+			t.Errorf("bucket %d has %d logs, want %d", i, bucket.Count, want[i])
+		}
+		if start := testStart.Add(time.Duration(i) * time.Minute); !bucket.Start.Equal(start) {
+			t.Errorf("bucket %d starts at %v, want %v", i, bucket.Start, start)
+		}
+	}
+}
+
+func TestDenseCountSeriesTooManyBuckets(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", 0),
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", time.Hour),
+	}
+	if _, err := logs.DenseCountSeries(time.Millisecond); err == nil {
+		t.Error("got no error for 3600000 buckets")
+	}
+}
+
+func TestVolumeErrorCorrelation(t *testing.T) {
+	logs := Logs{}
+	// Each minute doubles in volume along with its share of errors
+	for minute, volume := range []int{2, 4, 8} {
+		for i := 0; i < volume; i++ {
+			level := "INFO"
+			if i < volume/2*minute/2 {
+				level = ErrorLevel
+			}
+			offset := time.Duration(minute)*time.Minute + time.Duration(i)*time.Second
+			logs = append(logs, newLog("a", "webserver", "/login", level, "Logging in user", offset))
+		}
+	}
+	if got := logs.VolumeErrorCorrelation(time.Minute); !(got > 0.9) {
+		t.Errorf("got %v, want a strong positive correlation", got)
+	}
+	steady := logs[:2]
+	if got := steady.VolumeErrorCorrelation(time.Minute); !math.IsNaN(got) {
+		t.Errorf("got %v for a single bucket, want NaN", got)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Services returns the name of every service that wrote to Logs, sorted by name
+func (logs *Logs) Services() []string {
+	seen := map[string]bool{}
+	services := []string{}
+	for _, log := range *logs {
+		if !seen[log.Service] {
+			seen[log.Service] = true
+			services = append(services, log.Service)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// LateAppearingServices returns the services whose first log occurs only after
+// afterFraction (between 0 and 1) of the time range covered by Logs has elapsed,
+// sorted by name. These are services that "turned on" partway through the capture
+func (logs *Logs) LateAppearingServices(afterFraction float64) []string {
+	earliest, latest := logs.TimeRange()
+	cutoff := earliest.Add(time.Duration(float64(latest.Sub(earliest)) * afterFraction))
+	// Find the first time each service logged
+	firstSeen := map[string]time.Time{}
+	for _, log := range *logs {
+		seen, ok := firstSeen[log.Service]
+		if !ok || log.Timestamp.Before(seen) {
+			firstSeen[log.Service] = log.Timestamp.Time
+		}
+	}
+	services := []string{}
+	for service, seen := range firstSeen {
+		if seen.After(cutoff) {
+			services = append(services, service)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Levels are the known log levels, from least to most severe
+var Levels = []string{"DEBUG", "INFO", "WARNING", ErrorLevel}
+
+// LevelMatrix is the number of logs per level, indexed by service then level
+type LevelMatrix map[string]map[string]int
+
+// Columns returns the levels logged in LevelMatrix, starting with those in
+// Levels in order and followed by any other levels sorted by name. Levels no
+// service logged, such as excluded ones, are left out
+func (matrix LevelMatrix) Columns() []string {
+	present := map[string]bool{}
+	for _, counts := range matrix {
+		for level := range counts {
+			present[level] = true
+		}
+	}
+	columns := []string{}
+	for _, level := range Levels {
+		if present[level] {
+			columns = append(columns, level)
+			delete(present, level)
+		}
+	}
+	extra := []string{}
+	for level := range present {
+		extra = append(extra, level)
+	}
+	sort.Strings(extra)
+	return append(columns, extra...)
+}
+
+// Services returns the services in LevelMatrix, sorted by name
+func (matrix LevelMatrix) Services() []string {
+	services := []string{}
+	for service := range matrix {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// String formats LevelMatrix as a grid with a row per service and a column per level
+func (matrix LevelMatrix) String() string {
+	columns := matrix.Columns()
+	buffer := bytes.Buffer{}
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "SERVICE\t%s\n", strings.Join(columns, "\t"))
+	for _, service := range matrix.Services() {
+		row := []string{service}
+		for _, level := range columns {
+			row = append(row, strconv.Itoa(matrix[service][level]))
+		}
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	writer.Flush()
+	return buffer.String()
+}
+
+// LevelCountsByService returns the number of logs per level for each service.
+// Levels a service never logged are missing, and read as zero
+func (logs *Logs) LevelCountsByService() LevelMatrix {
+	matrix := LevelMatrix{}
+	for _, log := range *logs {
+		if matrix[log.Service] == nil {
+			matrix[log.Service] = map[string]int{}
+		}
+		matrix[log.Service][log.Level] += log.Occurrences()
+	}
+	return matrix
+}
+
+// ServiceCoverage returns the fraction of transactions that include at least
+// one log from service, or 0 if there are no transactions
+func (logs *Logs) ServiceCoverage(service string) float64 {
+	transactions := map[string]bool{}
+	for _, log := range *logs {
+		transactions[log.TransactionID] = transactions[log.TransactionID] || log.Service == service
+	}
+	if len(transactions) == 0 {
+		return 0
+	}
+	covered := 0
+	for _, touched := range transactions {
+		if touched {
+			covered++
+		}
+	}
+	return float64(covered) / float64(len(transactions))
+}
+
+// ParseCoverageRequirement parses a requirement of the form service:fraction,
+// such as auth:0.99, into the service and the minimum coverage it must reach
+func ParseCoverageRequirement(requirement string) (string, float64, error) {
+	separator := strings.LastIndex(requirement, ":")
+	if separator < 1 {
+		return "", 0, fmt.Errorf("coverage requirement %q must be of the form service:fraction", requirement)
+	}
+	minimum, err := strconv.ParseFloat(requirement[separator+1:], 64)
+	if err != nil || minimum < 0 || minimum > 1 {
+		return "", 0, fmt.Errorf("coverage requirement %q must have a fraction between 0 and 1", requirement)
+	}
+	return requirement[:separator], minimum, nil
+}
+
+// EntryServiceDistribution returns, for each service, the number of
+// transactions whose earliest log it wrote, identifying the common front doors
+func (logs *Logs) EntryServiceDistribution() map[string]int {
+	entries := map[string]int{}
+	for _, list := range logs.Transactions() {
+		entries[list[0].Service]++
+	}
+	return entries
+}
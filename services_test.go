@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLateAppearingServices(t *testing.T) {
+	logs := Logs{
+		newLog("a", "loadbalancer", "GET", "INFO", "START /index requested", 0),
+		newLog("a", "webserver", "/index", "INFO", "START Unauthenticated request", 10*time.Second),
+		newLog("b", "loadbalancer", "GET", "INFO", "START /index requested", 50*time.Second),
+		newLog("b", "cache_service", "Get", "INFO", "START Reading cache", 90*time.Second),
+		newLog("b", "loadbalancer", "GET", "INFO", "END /index requested", 100*time.Second),
+	}
+	got := logs.LateAppearingServices(0.8)
+	if want := []string{"cache_service"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLevelCountsByService(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", 0),
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", time.Second),
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", 2*time.Second),
+		newLog("a", "db_service", "GetUser", "DEBUG", "Querying users", 3*time.Second),
+	}
+	matrix := logs.LevelCountsByService()
+	cells := []struct {
+		service, level string
+		want           int
+	}{
+		{"webserver", "INFO", 1},
+		{"webserver", "ERROR", 2},
+		{"webserver", "DEBUG", 0},
+		{"db_service", "DEBUG", 1},
+		{"db_service", "ERROR", 0},
+	}
+	for _, cell := range cells {
+		if got := matrix[cell.service][cell.level]; got != cell.want {
+			t.Errorf("got %d %s logs for %s, want %d", got, cell.level, cell.service, cell.want)
+		}
+	}
+}
+
+func TestServiceCoverage(t *testing.T) {
+	logs := Logs{}
+	for i, id := range []string{"a", "b", "c", "d"} {
+		offset := time.Duration(i) * time.Minute
+		logs = append(logs, newLog(id, "loadbalancer", "POST", "INFO", "START /login requested", offset))
+		if id != "d" {
+			logs = append(logs, newLog(id, "authentication_service", "AuthenticateUser", "INFO", "START Authenticating user", offset+time.Second))
+		}
+	}
+	if got := logs.ServiceCoverage("authentication_service"); got != 0.75 {
+		t.Errorf("got %v, want 0.75", got)
+	}
+	if got := logs.ServiceCoverage("db_service"); got != 0 {
+		t.Errorf("got %v for a missing service, want 0", got)
+	}
+	tracker := NewTransactionTracker(2)
+	tracker.CoveredService = "authentication_service"
+	for i := range logs {
+		tracker.Add(&logs[i])
+	}
+	tracker.Flush()
+	if got := tracker.Coverage(); got != 0.75 {
+		t.Errorf("got %v while streaming, want 0.75", got)
+	}
+}
+
+func TestExcludeLevels(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "DEBUG", "Checking password", 0),
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", time.Second),
+		newLog("a", "webserver", "/login", "WARNING", "Slow login", 2*time.Second),
+		newLog("a", "db_service", "GetUser", "ERROR", "Timed out", 3*time.Second),
+	}
+	kept := logs.Filter(ExcludeLevels("DEBUG, INFO"))
+	if len(kept) != 2 {
+		t.Fatalf("got %d logs, want 2", len(kept))
+	}
+	if got, want := kept.LevelCountsByService().Columns(), []string{"WARNING", ErrorLevel}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got columns %v, want %v", got, want)
+	}
+	buffer := bytes.Buffer{}
+	WriteText(&buffer, NewReport(Analyses, &kept, false))
+	for _, level := range []string{"DEBUG", "INFO"} {
+		if strings.Contains(buffer.String(), level) {
+			t.Errorf("excluded level %s appears in\n%s", level, buffer.String())
+		}
+	}
+}
+
+func TestEntryServiceDistribution(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", time.Second),
+		newLog("a", "loadbalancer", "POST", "INFO", "START /login requested", 0),
+		newLog("b", "loadbalancer", "GET", "INFO", "START /index requested", 0),
+		newLog("c", "account_service", "GetAccount", "INFO", "START Retrieving user account information", 0),
+		newLog("c", "db_service", "GetUser", "INFO", "START Retrieving user information", time.Second),
+	}
+	want := map[string]int{"loadbalancer": 2, "account_service": 1}
+	if got := logs.EntryServiceDistribution(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
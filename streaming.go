@@ -0,0 +1,276 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// openTransaction is the span of a transaction seen so far in a stream of
+// logs: its earliest and latest logs, and its earliest START and latest END
+type openTransaction struct {
+	id    string
+	first time.Time
+	last  time.Time
+	start *time.Time
+	end   *time.Time
+	// covered is whether a log of the transaction was written by the
+	// tracker's CoveredService
+	covered bool
+}
+
+// TransactionTracker measures transaction durations from a stream of logs
+// while holding at most a fixed number of open transactions in memory. When
+// full, the least recently updated transaction is finalized, which assumes
+// logs arrive roughly in time order: a transaction that logs again after being
+// finalized is measured as a new transaction. Durations are measured like
+// OutermostDuration, and negative ones are left out unless
+// AllowNegativeDurations is set
+type TransactionTracker struct {
+	// Count is the number of logs added to the tracker
+	Count int
+	// Longest is the longest transaction finalized so far
+	Longest TransactionDuration
+	// CoveredService, if set, is the service whose coverage is measured
+	CoveredService string
+	// Transactions is the number of transactions finalized so far, and
+	// Covered how many of them included a log from CoveredService
+	Transactions int
+	Covered      int
+
+	maxOpen int
+	open    map[string]*list.Element
+	// recent orders open transactions from most to least recently updated
+	recent *list.List
+	// negative lists the finalized transactions with a negative duration
+	negative []string
+}
+
+// NewTransactionTracker returns a TransactionTracker holding at most maxOpen
+// open transactions
+func NewTransactionTracker(maxOpen int) *TransactionTracker {
+	return &TransactionTracker{
+		maxOpen: maxOpen,
+		open:    map[string]*list.Element{},
+		recent:  list.New(),
+	}
+}
+
+// Add extends the span of the log's transaction to include it, finalizing the
+// least recently updated transaction if too many are open
+func (tracker *TransactionTracker) Add(log *Log) {
+	tracker.Count += log.Occurrences()
+	element, ok := tracker.open[log.TransactionID]
+	if !ok {
+		if tracker.recent.Len() >= tracker.maxOpen {
+			tracker.finalize(tracker.recent.Back())
+		}
+		transaction := &openTransaction{id: log.TransactionID, first: log.Timestamp.Time, last: log.LastTimestamp()}
+		transaction.add(log)
+		transaction.covered = log.Service == tracker.CoveredService
+		tracker.open[log.TransactionID] = tracker.recent.PushFront(transaction)
+		return
+	}
+	transaction := element.Value.(*openTransaction)
+	transaction.add(log)
+	transaction.covered = transaction.covered || log.Service == tracker.CoveredService
+	tracker.recent.MoveToFront(element)
+}
+
+// add extends the span of an open transaction to include log
+func (transaction *openTransaction) add(log *Log) {
+	at, last := log.Timestamp.Time, log.LastTimestamp()
+	if at.Before(transaction.first) {
+		transaction.first = at
+	}
+	if last.After(transaction.last) {
+		transaction.last = last
+	}
+	if log.IsStart() && (transaction.start == nil || at.Before(*transaction.start)) {
+		transaction.start = &at
+	}
+	if log.IsEnd() && (transaction.end == nil || last.After(*transaction.end)) {
+		transaction.end = &last
+	}
+}
+
+// Flush finalizes every open transaction, once the stream has ended
+func (tracker *TransactionTracker) Flush() {
+	for tracker.recent.Len() > 0 {
+		tracker.finalize(tracker.recent.Back())
+	}
+}
+
+// finalize stops tracking an open transaction, recording its duration
+func (tracker *TransactionTracker) finalize(element *list.Element) {
+	transaction := tracker.recent.Remove(element).(*openTransaction)
+	delete(tracker.open, transaction.id)
+	duration := transaction.duration()
+	if duration.Duration < 0 {
+		tracker.negative = append(tracker.negative, duration.ID)
+	}
+	tracker.Longest = longer(tracker.Longest, duration)
+	tracker.Transactions++
+	if transaction.covered {
+		tracker.Covered++
+	}
+}
+
+// NegativeDurations returns the IDs of transactions finalized so far, sorted,
+// that measure a negative duration, like Logs.NegativeDurations
+func (tracker *TransactionTracker) NegativeDurations() []string {
+	ids := append([]string{}, tracker.negative...)
+	sort.Strings(ids)
+	return ids
+}
+
+// Coverage returns the fraction of transactions finalized so far that include
+// a log from CoveredService, like ServiceCoverage, or 0 if there are none
+func (tracker *TransactionTracker) Coverage() float64 {
+	if tracker.Transactions == 0 {
+		return 0
+	}
+	return float64(tracker.Covered) / float64(tracker.Transactions)
+}
+
+// longer returns the longer of two transactions, or the one with the lower ID
+// if they are equally long. A transaction with no ID is shorter than any
+// other, as is a negative duration unless AllowNegativeDurations is set
+func longer(a, b TransactionDuration) TransactionDuration {
+	if b.Duration < 0 && !AllowNegativeDurations {
+		return a
+	}
+	if a.ID == "" || b.Duration > a.Duration || (b.Duration == a.Duration && b.ID < a.ID) {
+		return b
+	}
+	return a
+}
+
+// LongestSoFar returns the longest transaction seen so far, measuring open
+// transactions up to their latest log
+func (tracker *TransactionTracker) LongestSoFar() TransactionDuration {
+	longest := tracker.Longest
+	for element := tracker.recent.Front(); element != nil; element = element.Next() {
+		longest = longer(longest, element.Value.(*openTransaction).duration())
+	}
+	return longest
+}
+
+// duration returns the span of an open transaction as a TransactionDuration,
+// measured like OutermostDuration
+func (transaction *openTransaction) duration() TransactionDuration {
+	start, end := transaction.first, transaction.last
+	if transaction.start != nil {
+		start = *transaction.start
+	}
+	if transaction.end != nil {
+		end = *transaction.end
+	}
+	return TransactionDuration{ID: transaction.id, Duration: end.Sub(start)}
+}
+
+// DefaultMaxOpenTransactions is the number of open transactions tracked while
+// streaming a URL, unless -max-open-transactions is given
+const DefaultMaxOpenTransactions = 10000
+
+// DefaultRollingInterval is how often the results so far are printed while
+// streaming a URL, unless -rolling is given
+const DefaultRollingInterval = 10 * time.Second
+
+// StreamingFlags are the command line flags that apply when streaming a URL or
+// with -max-open-transactions. The others need every log in memory at once
+var StreamingFlags = map[string]bool{
+	"max-open-transactions": true,
+	"rolling":               true,
+	"only":                  true,
+	"output":                true,
+	"float-precision":       true,
+	"with-percent":          true,
+	"base64":                true,
+	"tz":                    true,
+	"where":                 true,
+	"exclude-levels":        true,
+	"allow-negative":        true,
+	"require-coverage":      true,
+}
+
+// StreamAnalyses are the analyses that can be computed from a TransactionTracker,
+// indexed by the name of the matching Analysis
+var StreamAnalyses = map[string]func(tracker *TransactionTracker) interface{}{
+	"total": func(tracker *TransactionTracker) interface{} {
+		return tracker.Count
+	},
+	"longest-transaction": func(tracker *TransactionTracker) interface{} {
+		return tracker.LongestSoFar()
+	},
+}
+
+// Stream runs Analyses over logs as they are read, without holding them in
+// memory, measuring transactions with Tracker. Only the analyses in
+// StreamAnalyses are supported
+type Stream struct {
+	Analyses []Analysis
+	Tracker  *TransactionTracker
+	// Keep, if set, drops the logs it does not match before they are tracked
+	Keep Predicate
+	// Rolling, if set, is called with a Partial Report of the logs read so
+	// far whenever RollingInterval has passed since the last one, such as
+	// while tailing an HTTP stream that stays open
+	Rolling         func(report Report) error
+	RollingInterval time.Duration
+}
+
+// Report runs the analyses of stream over the logs in fileName. If ctx is
+// done before the input ends, the logs read so far are reported and the
+// Report is marked Partial
+func (stream *Stream) Report(ctx context.Context, fileName string) (Report, error) {
+	for _, analysis := range stream.Analyses {
+		if StreamAnalyses[analysis.Name] == nil {
+			return Report{}, fmt.Errorf("analysis %q is not available when streaming", analysis.Name)
+		}
+	}
+	input, err := OpenInput(ctx, fileName)
+	if err != nil {
+		return Report{}, err
+	}
+	defer input.Close()
+
+	var rollingErr error
+	lastRolling := time.Now()
+	err = DecodeEach(ctx, input, func(log *Log) {
+		if stream.Keep != nil && !stream.Keep(log) {
+			return
+		}
+		stream.Tracker.Add(log)
+		if stream.Rolling == nil || rollingErr != nil || time.Since(lastRolling) < stream.RollingInterval {
+			return
+		}
+		report := stream.report()
+		report.Partial = true
+		rollingErr = stream.Rolling(report)
+		lastRolling = time.Now()
+	})
+	if rollingErr != nil {
+		return Report{}, rollingErr
+	}
+	// Reading an HTTP stream fails when ctx is done, rather than stopping cleanly
+	if err != nil && ctx.Err() == nil {
+		return Report{}, err
+	}
+	stream.Tracker.Flush()
+	report := stream.report()
+	report.Partial = ctx.Err() != nil
+	return report, nil
+}
+
+// report runs the analyses of stream over the logs tracked so far
+func (stream *Stream) report() Report {
+	report := Report{SchemaVersion: SchemaVersion, Results: Results{}}
+	for _, analysis := range stream.Analyses {
+		value := StreamAnalyses[analysis.Name](stream.Tracker)
+		report.Results = append(report.Results, Result{analysis.Name, analysis.Label, value})
+	}
+	return report
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestStreamRollingHTTP(t *testing.T) {
+	lines := []string{
+		`{"service": "loadbalancer", "level": "INFO", "timestamp": "2017-10-17 12:00:00.000000", "operation": "GET", "message": "START /index requested", "transaction_id": "a"}`,
+		`{"service": "loadbalancer", "level": "INFO", "timestamp": "2017-10-17 12:00:01.000000", "operation": "GET", "message": "START /index requested", "transaction_id": "b"}`,
+		`{"service": "loadbalancer", "level": "INFO", "timestamp": "2017-10-17 12:00:03.000000", "operation": "GET", "message": "END /index requested", "transaction_id": "a"}`,
+	}
+	handled := make(chan Report)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+			w.(http.Flusher).Flush()
+			// Send the next line only once this one has been reported on,
+			// proving lines are analyzed as they arrive
+			select {
+			case <-handled:
+			case <-time.After(5 * time.Second):
+				t.Error("timed out waiting for a line to be reported")
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	analyses, _ := SelectAnalyses("total,longest-transaction")
+	rolling := []Report{}
+	stream := Stream{
+		Analyses: analyses,
+		Tracker:  NewTransactionTracker(10),
+		Rolling: func(report Report) error {
+			rolling = append(rolling, report)
+			handled <- report
+			return nil
+		},
+	}
+	report, err := stream.Report(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rolling) != len(lines) {
+		t.Fatalf("got %d rolling reports, want %d", len(rolling), len(lines))
+	}
+	for i, partial := range rolling {
+		if !partial.Partial || partial.Results[0].Value != i+1 {
+			t.Errorf("rolling report %d has total %v and partial %v, want %d and true", i, partial.Results[0].Value, partial.Partial, i+1)
+		}
+	}
+	longest := TransactionDuration{ID: "a", Duration: 3 * time.Second}
+	if report.Partial || report.Results[0].Value != 3 || report.Results[1].Value != longest {
+		t.Errorf("got %v, want a complete report of 3 logs with %v longest", report, longest)
+	}
+}
+
+func TestTransactionTrackerMatchesUnbounded(t *testing.T) {
+	// Transactions start a second apart and last up to 6s, so fewer than 20
+	// log while any one of them is open, and none is finalized early
+	logs := Logs{}
+	for i := 0; i < 200; i++ {
+		logs = append(logs, transaction(fmt.Sprint(i), time.Duration(i)*time.Second, time.Duration(i%7)*time.Second)...)
+	}
+	sort.Stable(logs)
+	tracker := NewTransactionTracker(20)
+	for i := range logs {
+		tracker.Add(&logs[i])
+	}
+	tracker.Flush()
+	if got, want := tracker.Longest, logs.LongestTransaction(); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if tracker.Count != len(logs) {
+		t.Errorf("got %d logs, want %d", tracker.Count, len(logs))
+	}
+}
+
+func TestTransactionTrackerNegativeDurations(t *testing.T) {
+	logs := transaction("ok", 0, time.Second)
+	// Logs its END 4s before its START, so it measures -4s
+	logs = append(logs, transaction("skewed", 5*time.Second, -4*time.Second)...)
+	tracker := NewTransactionTracker(10)
+	for i := range logs {
+		tracker.Add(&logs[i])
+	}
+	tracker.Flush()
+	if got, want := tracker.NegativeDurations(), logs.NegativeDurations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got negative durations %v, want %v", got, want)
+	}
+	if tracker.Longest.ID != "ok" {
+		t.Errorf("got longest transaction %v, want ok", tracker.Longest)
+	}
+}
+
+func TestStreamKeep(t *testing.T) {
+	keep := Not(ByLevel("DEBUG", "INFO"))
+	analyses, _ := SelectAnalyses("total,longest-transaction")
+	stream := Stream{Analyses: analyses, Tracker: NewTransactionTracker(100), Keep: keep}
+	report, err := stream.Report(context.Background(), "testdata/logs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs, err := ReadLogs("testdata/logs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := logs.Filter(keep)
+	if want := RunAnalyses(analyses, &kept); !reflect.DeepEqual(report.Results, want) {
+		t.Errorf("got %v, want %v", report.Results, want)
+	}
+}
+
+func TestStreamCancelIsPartial(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	read := 0
+	analyses, _ := SelectAnalyses("total")
+	stream := Stream{
+		Analyses: analyses,
+		Tracker:  NewTransactionTracker(100),
+		// Interrupt the stream once the third log has been read
+		Keep: func(log *Log) bool {
+			read++
+			if read == 3 {
+				cancel()
+			}
+			return true
+		},
+	}
+	report, err := stream.Report(ctx, "testdata/logs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Partial || report.Results[0].Value != 3 {
+		t.Errorf("got total %v and partial %v, want 3 and true", report.Results[0].Value, report.Partial)
+	}
+	buffer := bytes.Buffer{}
+	WriteText(&buffer, report)
+	if want := "Partial results: covers only the logs read so far\nTotal Log Entries: 3\n"; buffer.String() != want {
+		t.Errorf("got %q, want %q", buffer.String(), want)
+	}
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TransactionDuration is how long a single transaction took
+type TransactionDuration struct {
+	ID       string        `json:"id"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// String formats TransactionDuration as the transaction ID followed by its duration
+func (transaction TransactionDuration) String() string {
+	return fmt.Sprintf("%s (%s)", transaction.ID, transaction.Duration)
+}
+
+// Transactions returns a map of Logs indexed by the log.TransactionID field,
+// with the Logs of each transaction sorted by Timestamp
+func (logs *Logs) Transactions() map[string]Logs {
+	transactions := map[string]Logs{}
+	for _, log := range *logs {
+		transactions[log.TransactionID] = append(transactions[log.TransactionID], log)
+	}
+	for _, list := range transactions {
+		sort.Stable(list)
+	}
+	return transactions
+}
+
+// TransactionRanking is a list of transactions ordered by duration
+type TransactionRanking []TransactionDuration
+
+// String formats TransactionRanking as a comma-separated list of transactions
+func (ranking TransactionRanking) String() string {
+	formatted := []string{}
+	for _, transaction := range ranking {
+		formatted = append(formatted, transaction.String())
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// DurationFunc measures the duration of a single transaction from its Logs,
+// sorted by Timestamp. It returns false if the transaction cannot be measured
+type DurationFunc func(list Logs) (time.Duration, bool)
+
+// OutermostDuration measures a transaction from the START of its first
+// operation to the END of its last. Clock skew can log that END before the
+// START, giving a negative duration. Transactions without START or END logs
+// are measured from their first or last timestamp instead. A collapsed log
+// ends at its LastTimestamp
+func OutermostDuration(list Logs) (time.Duration, bool) {
+	if len(list) == 0 {
+		return 0, false
+	}
+	start, end := list.TimeRange()
+	firstStart, firstEnd := true, true
+	for i := range list {
+		log := &list[i]
+		if log.IsStart() && firstStart {
+			start = log.Timestamp.Time
+			firstStart = false
+		}
+		if last := log.LastTimestamp(); log.IsEnd() && (firstEnd || last.After(end)) {
+			end = last
+			firstEnd = false
+		}
+	}
+	// https://stackoverflow.com/questions/40260599/difference-between-two-time-time-objects/40260666
+	return end.Sub(start), true
+}
+
+// OperationSpan returns a DurationFunc measuring a transaction from the first
+// log of the from operation to the last log of the to operation. Transactions
+// missing either operation cannot be measured. An empty from or to stands for
+// the first or last log of the transaction. A collapsed log ends at its
+// LastTimestamp
+func OperationSpan(from, to string) DurationFunc {
+	return func(list Logs) (time.Duration, bool) {
+		var start, end *Log
+		for i := range list {
+			log := &list[i]
+			if start == nil && (from == "" || log.Operation == from) {
+				start = log
+			}
+			if to == "" || log.Operation == to {
+				end = log
+			}
+		}
+		if start == nil || end == nil {
+			return 0, false
+		}
+		return end.LastTimestamp().Sub(start.Timestamp.Time), true
+	}
+}
+
+// MeasureTransaction is the DurationFunc used by every transaction duration
+// metric. It defaults to OutermostDuration
+var MeasureTransaction DurationFunc = OutermostDuration
+
+// AllowNegativeDurations keeps transactions that measure a negative duration,
+// usually because of clock skew between services, in duration metrics
+var AllowNegativeDurations = false
+
+// SplitReusedTransactionIDs returns the Logs grouped by transaction like
+// Transactions, except that a transaction is split wherever two consecutive
+// logs are more than gapThreshold apart. A gap that large suggests the ID was
+// reused by a separate request rather than one long transaction. The first
+// part keeps the original ID, and later parts add a count, such as "id#2".
+// Counts already taken by another transaction's ID are skipped
+func (logs *Logs) SplitReusedTransactionIDs(gapThreshold time.Duration) map[string]Logs {
+	split := map[string]Logs{}
+	transactions := logs.Transactions()
+	for id, list := range transactions {
+		part := 1
+		start := 0
+		for i := 1; i <= len(list); i++ {
+			if i < len(list) && list[i].Timestamp.Sub(list[i-1].LastTimestamp()) <= gapThreshold {
+				continue
+			}
+			partID := id
+			for part > 1 {
+				partID = fmt.Sprintf("%s#%d", id, part)
+				if _, taken := transactions[partID]; !taken {
+					break
+				}
+				part++
+			}
+			split[partID] = list[start:i]
+			part++
+			start = i
+		}
+	}
+	return split
+}
+
+// TransactionDurations returns the duration of each transaction, indexed by
+// transaction ID, as measured by MeasureTransaction. Transactions that cannot
+// be measured are left out, as are negative durations unless
+// AllowNegativeDurations is set
+func (logs *Logs) TransactionDurations() map[string]time.Duration {
+	durations := logs.measureTransactions()
+	if !AllowNegativeDurations {
+		for id, duration := range durations {
+			if duration < 0 {
+				delete(durations, id)
+			}
+		}
+	}
+	return durations
+}
+
+// NegativeDurations returns the IDs of transactions, sorted, that measure a
+// negative duration. These indicate bad data such as clock skew
+func (logs *Logs) NegativeDurations() []string {
+	ids := []string{}
+	for id, duration := range logs.measureTransactions() {
+		if duration < 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// measureTransactions returns the duration of every transaction that
+// MeasureTransaction can measure, indexed by transaction ID
+func (logs *Logs) measureTransactions() map[string]time.Duration {
+	durations := map[string]time.Duration{}
+	for id, list := range logs.Transactions() {
+		duration, ok := MeasureTransaction(list)
+		if ok {
+			durations[id] = duration
+		}
+	}
+	return durations
+}
+
+// DurationHistogram returns the number of transactions whose duration falls
+// in each bucket, indexed by the start of the bucket. Durations are truncated
+// to a multiple of bucket
+func (logs *Logs) DurationHistogram(bucket time.Duration) map[time.Duration]int {
+	histogram := map[time.Duration]int{}
+	for _, duration := range logs.TransactionDurations() {
+		histogram[duration.Truncate(bucket)]++
+	}
+	return histogram
+}
+
+// TopLongestTransactions returns the n transactions with the longest durations,
+// longest first. Transactions with equal durations are ordered by ID. A
+// negative n is treated as zero
+func (logs *Logs) TopLongestTransactions(n int) []TransactionDuration {
+	if n < 0 {
+		n = 0
+	}
+	ranking := []TransactionDuration{}
+	for id, duration := range logs.TransactionDurations() {
+		ranking = append(ranking, TransactionDuration{ID: id, Duration: duration})
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].Duration != ranking[j].Duration {
+			return ranking[i].Duration > ranking[j].Duration
+		}
+		return ranking[i].ID < ranking[j].ID
+	})
+	if n < len(ranking) {
+		ranking = ranking[:n]
+	}
+	return ranking
+}
+
+// MedianTransactionDuration returns the median duration across all transactions.
+// With an even number of transactions, the two middle durations are averaged
+func (logs *Logs) MedianTransactionDuration() time.Duration {
+	durations := []time.Duration{}
+	for _, duration := range logs.TransactionDurations() {
+		durations = append(durations, duration)
+	}
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+	middle := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[middle-1] + durations[middle]) / 2
+	}
+	return durations[middle]
+}
+
+// ErrorBeforeSuccess returns the IDs of transactions, sorted, whose first error
+// was logged before the first log of successOp, suggesting the error happened
+// before the request was properly handled. Transactions that never run
+// successOp are not included
+func (logs *Logs) ErrorBeforeSuccess(successOp string) []string {
+	ids := []string{}
+	for id, list := range logs.Transactions() {
+		var firstError, firstSuccess *Log
+		for i := range list {
+			log := &list[i]
+			if firstError == nil && log.IsError() {
+				firstError = log
+			}
+			if firstSuccess == nil && log.Operation == successOp {
+				firstSuccess = log
+			}
+		}
+		if firstError != nil && firstSuccess != nil && firstError.Timestamp.Before(firstSuccess.Timestamp.Time) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// VolumeOutliers returns the IDs of transactions, sorted, whose log count is
+// more than zThreshold standard deviations above the mean log count. These
+// often point at runaway loops. With fewer than two transactions there is no
+// spread to measure against, so nothing is returned
+func (logs *Logs) VolumeOutliers(zThreshold float64) []string {
+	outliers := []string{}
+	transactions := logs.Transactions()
+	if len(transactions) < 2 {
+		return outliers
+	}
+	total := 0.0
+	for _, list := range transactions {
+		total += float64(list.Occurrences())
+	}
+	mean := total / float64(len(transactions))
+	variance := 0.0
+	for _, list := range transactions {
+		variance += math.Pow(float64(list.Occurrences())-mean, 2)
+	}
+	stddev := math.Sqrt(variance / float64(len(transactions)))
+	if stddev == 0 {
+		return outliers
+	}
+	for id, list := range transactions {
+		if (float64(list.Occurrences())-mean)/stddev > zThreshold {
+			outliers = append(outliers, id)
+		}
+	}
+	sort.Strings(outliers)
+	return outliers
+}
+
+// TransactionSummary describes a single transaction for export
+type TransactionSummary struct {
+	ID    string    `json:"id"`
+	Start time.Time `json:"start"`
+	// Duration is nil for a transaction left out of duration metrics
+	Duration *time.Duration `json:"duration_ns,omitempty"`
+	LogCount int            `json:"log_count"`
+}
+
+// TransactionSummaries returns a TransactionSummary for each transaction,
+// sorted by start time. Transactions that cannot be measured, or whose
+// negative durations are left out, have no Duration
+func (logs *Logs) TransactionSummaries() []TransactionSummary {
+	durations := logs.TransactionDurations()
+	summaries := []TransactionSummary{}
+	for id, list := range logs.Transactions() {
+		summary := TransactionSummary{ID: id, Start: list[0].Timestamp.Time, LogCount: list.Occurrences()}
+		if duration, ok := durations[id]; ok {
+			summary.Duration = &duration
+		}
+		summaries = append(summaries, summary)
+	}
+	SortTransactionSummaries(summaries, "start")
+	return summaries
+}
+
+// transactionOrders compare two transactions for each supported sort order
+var transactionOrders = map[string]func(a, b *TransactionSummary) bool{
+	"start": func(a, b *TransactionSummary) bool {
+		return a.Start.Before(b.Start)
+	},
+	"duration": func(a, b *TransactionSummary) bool {
+		if a.Duration == nil || b.Duration == nil {
+			return b.Duration == nil && a.Duration != nil
+		}
+		return *a.Duration > *b.Duration
+	},
+	"count": func(a, b *TransactionSummary) bool {
+		return a.LogCount > b.LogCount
+	},
+}
+
+// SortTransactionSummaries sorts summaries by start time (earliest first),
+// duration (longest first, then those without one) or log count (most first).
+// Transactions that are equal in that order are sorted by ID
+func SortTransactionSummaries(summaries []TransactionSummary, by string) error {
+	before, ok := transactionOrders[by]
+	if !ok {
+		return fmt.Errorf("unknown transaction sort order %q", by)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if before(&summaries[i], &summaries[j]) {
+			return true
+		}
+		if before(&summaries[j], &summaries[i]) {
+			return false
+		}
+		return summaries[i].ID < summaries[j].ID
+	})
+	return nil
+}
+
+// Transaction returns the Logs of transaction id sorted by Timestamp
+func (logs *Logs) Transaction(id string) Logs {
+	list := logs.Filter(func(log *Log) bool {
+		return log.TransactionID == id
+	})
+	sort.Stable(list)
+	return list
+}
+
+// NormalizedTransaction returns the Logs of transaction id sorted by Timestamp,
+// keeping only the first of any logs sharing the same timestamp, operation
+// and message
+func (logs *Logs) NormalizedTransaction(id string) Logs {
+	list := logs.Transaction(id)
+	type logKey struct {
+		timestamp time.Time
+		operation string
+		message   string
+	}
+	seen := map[logKey]bool{}
+	normalized := Logs{}
+	for _, log := range list {
+		key := logKey{log.Timestamp.Time, log.Operation, log.Message}
+		if !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, log)
+		}
+	}
+	return normalized
+}
+
+// HerdStarts returns the start of each window, window long, in which at least
+// minCount transactions logged their first entry, flagging load spikes from
+// many transactions starting together. Windows are found in time order, and
+// the search resumes after the last start counted in a flagged window, so
+// one spike is reported once
+func (logs *Logs) HerdStarts(window time.Duration, minCount int) []time.Time {
+	starts := []time.Time{}
+	for _, list := range logs.Transactions() {
+		starts = append(starts, list[0].Timestamp.Time)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+	herds := []time.Time{}
+	for i := 0; i < len(starts); {
+		// Advance end past every start within window of starts[i]
+		end := i
+		for end < len(starts) && starts[end].Sub(starts[i]) <= window {
+			end++
+		}
+		// A negative window holds no starts, and would never advance i
+		if end > i && end-i >= minCount {
+			herds = append(herds, starts[i])
+			i = end
+			continue
+		}
+		i++
+	}
+	return herds
+}
+
+// MaxFanoutTransaction returns the ID of the transaction that touched the most
+// distinct services, and how many it touched. Transactions touching the same
+// number of services are ordered by ID, and the first is returned
+func (logs *Logs) MaxFanoutTransaction() (string, int) {
+	services := map[string]map[string]bool{}
+	for _, log := range *logs {
+		if services[log.TransactionID] == nil {
+			services[log.TransactionID] = map[string]bool{}
+		}
+		services[log.TransactionID][log.Service] = true
+	}
+	widest := ""
+	widestCount := 0
+	for id, touched := range services {
+		if len(touched) > widestCount || (len(touched) == widestCount && id < widest) {
+			widest = id
+			widestCount = len(touched)
+		}
+	}
+	return widest, widestCount
+}
+
+// SegmentByBoundary assigns synthetic transaction IDs to logs that have none,
+// as an alternative to grouping by ID. Each service's logs are taken in time
+// order and a new transaction begins at every log of the boundary operation,
+// other than the END logs that close it. Logs a service wrote before its first
+// boundary form a transaction of their own. Synthetic IDs are the service name
+// and a count, such as "webserver#1". Logs that already have an ID are kept
+func (logs *Logs) SegmentByBoundary(boundary string) Logs {
+	segmented := append(Logs{}, *logs...)
+	// Collect the positions of each service's logs that have no ID
+	positions := map[string][]int{}
+	for i, log := range segmented {
+		if log.TransactionID == "" {
+			positions[log.Service] = append(positions[log.Service], i)
+		}
+	}
+	for service, indices := range positions {
+		sort.SliceStable(indices, func(i, j int) bool {
+			return segmented[indices[i]].Timestamp.Before(segmented[indices[j]].Timestamp.Time)
+		})
+		segment := 0
+		for _, index := range indices {
+			log := &segmented[index]
+			if log.Operation == boundary && !log.IsEnd() {
+				segment++
+			}
+			log.TransactionID = fmt.Sprintf("%s#%d", service, segment)
+		}
+	}
+	return segmented
+}
+
+// TransactionsFullyWithin returns the IDs of transactions, sorted, whose every
+// log was written between start and end inclusive, such as those unambiguously
+// affected by a deploy. Transactions that only overlap the window are left out
+func (logs *Logs) TransactionsFullyWithin(start, end time.Time) []string {
+	ids := []string{}
+	for id, list := range logs.Transactions() {
+		first, last := list.TimeRange()
+		if !first.Before(start) && !last.After(end) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
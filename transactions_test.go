@@ -0,0 +1,326 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// transaction returns the START and END logs of a transaction lasting duration,
+// starting offset after testStart
+func transaction(id string, offset, duration time.Duration) Logs {
+	return Logs{
+		newLog(id, "loadbalancer", "GET", "INFO", "START /index requested", offset),
+		newLog(id, "loadbalancer", "GET", "INFO", "END /index requested", offset+duration),
+	}
+}
+
+func TestMedianTransactionDuration(t *testing.T) {
+	logs := Logs{}
+	logs = append(logs, transaction("a", 0, 3*time.Second)...)
+	logs = append(logs, transaction("b", 0, time.Second)...)
+	logs = append(logs, transaction("c", 0, 2*time.Second)...)
+	if got := logs.MedianTransactionDuration(); got != 2*time.Second {
+		t.Errorf("got %s with an odd count, want 2s", got)
+	}
+	logs = append(logs, transaction("d", 0, 10*time.Second)...)
+	if got := logs.MedianTransactionDuration(); got != 2500*time.Millisecond {
+		t.Errorf("got %s with an even count, want 2.5s", got)
+	}
+	empty := Logs{}
+	if got := empty.MedianTransactionDuration(); got != 0 {
+		t.Errorf("got %s without transactions, want 0s", got)
+	}
+}
+
+func TestErrorBeforeSuccess(t *testing.T) {
+	logs := Logs{
+		newLog("failed", "loadbalancer", "GET", "ERROR", "Bad request", 0),
+		newLog("failed", "webserver", "/login", "INFO", "START Logging in user", time.Second),
+		newLog("control", "webserver", "/login", "INFO", "START Logging in user", 0),
+		newLog("control", "webserver", "/login", "ERROR", "Login failed", time.Second),
+		newLog("never", "loadbalancer", "GET", "ERROR", "Bad request", 0),
+	}
+	got := logs.ErrorBeforeSuccess("/login")
+	if want := []string{"failed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOperationSpan(t *testing.T) {
+	list := Logs{
+		newLog("a", "loadbalancer", "GET", "INFO", "START /index requested", 0),
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", time.Second),
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", 3*time.Second),
+		newLog("a", "loadbalancer", "GET", "INFO", "END /index requested", 4*time.Second),
+	}
+	tests := []struct {
+		from, to string
+		want     time.Duration
+		ok       bool
+	}{
+		{"/login", "/login", 2 * time.Second, true},
+		{"/login", "", 3 * time.Second, true},
+		{"", "/login", 3 * time.Second, true},
+		{"GET", "GET", 4 * time.Second, true},
+		{"GetUser", "GET", 0, false},
+	}
+	for _, test := range tests {
+		got, ok := OperationSpan(test.from, test.to)(list)
+		if got != test.want || ok != test.ok {
+			t.Errorf("OperationSpan(%q, %q) = %s, %v, want %s, %v", test.from, test.to, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestTopLongestTransactions(t *testing.T) {
+	logs := Logs{}
+	logs = append(logs, transaction("short", 0, time.Second)...)
+	logs = append(logs, transaction("long", 0, 3*time.Second)...)
+	logs = append(logs, transaction("tied-b", 0, 2*time.Second)...)
+	logs = append(logs, transaction("tied-a", 0, 2*time.Second)...)
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{2, []string{"long", "tied-a"}},
+		{10, []string{"long", "tied-a", "tied-b", "short"}},
+		{0, []string{}},
+		{-1, []string{}},
+	}
+	for _, test := range tests {
+		got := []string{}
+		for _, transaction := range logs.TopLongestTransactions(test.n) {
+			got = append(got, transaction.ID)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("TopLongestTransactions(%d) = %v, want %v", test.n, got, test.want)
+		}
+	}
+}
+
+func TestVolumeOutliers(t *testing.T) {
+	logs := Logs{}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		logs = append(logs, transaction(id, 0, time.Second)...)
+	}
+	for i := 0; i < 20; i++ {
+		logs = append(logs, newLog("loop", "webserver", "/login", "INFO", "Retrying", time.Duration(i)*time.Millisecond))
+	}
+	got := logs.VolumeOutliers(1.5)
+	if want := []string{"loop"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	single := transaction("a", 0, time.Second)
+	if got := single.VolumeOutliers(0); len(got) != 0 {
+		t.Errorf("got %v for a single transaction, want none", got)
+	}
+}
+
+func TestNegativeDurations(t *testing.T) {
+	defer func() { AllowNegativeDurations = false }()
+	logs := transaction("ok", 0, time.Second)
+	// The END was logged by a service whose clock runs 4s behind
+	logs = append(logs,
+		newLog("skewed", "loadbalancer", "GET", "INFO", "END /index requested", time.Second),
+		newLog("skewed", "loadbalancer", "GET", "INFO", "START /index requested", 5*time.Second),
+	)
+	if got, want := logs.NegativeDurations(), []string{"skewed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got negative durations %v, want %v", got, want)
+	}
+	if _, ok := logs.TransactionDurations()["skewed"]; ok {
+		t.Error("skewed transaction was not excluded")
+	}
+	if got := logs.LongestTransaction().ID; got != "ok" {
+		t.Errorf("got longest transaction %s, want ok", got)
+	}
+	AllowNegativeDurations = true
+	if got := logs.TransactionDurations()["skewed"]; got != -4*time.Second {
+		t.Errorf("got skewed duration %s with negative durations allowed, want -4s", got)
+	}
+}
+
+func TestSortTransactionSummaries(t *testing.T) {
+	logs := Logs{}
+	logs = append(logs, transaction("longest", time.Second, 5*time.Second)...)
+	logs = append(logs, transaction("first", 0, 2*time.Second)...)
+	logs = append(logs, newLog("first", "webserver", "/index", "INFO", "Rendering page", time.Second))
+	logs = append(logs, transaction("busiest", 2*time.Second, time.Second)...)
+	logs = append(logs,
+		newLog("busiest", "webserver", "/index", "INFO", "Rendering page", 2*time.Second),
+		newLog("busiest", "webserver", "/index", "INFO", "Rendering page", 2*time.Second),
+	)
+	// Logs its END 1s before its START, so it is left out of duration metrics
+	logs = append(logs, transaction("skewed", 3*time.Second, -time.Second)...)
+	orders := map[string][]string{
+		"start":    {"first", "longest", "busiest", "skewed"},
+		"duration": {"longest", "first", "busiest", "skewed"},
+		"count":    {"busiest", "first", "longest", "skewed"},
+	}
+	for by, want := range orders {
+		summaries := logs.TransactionSummaries()
+		if err := SortTransactionSummaries(summaries, by); err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, summary := range summaries {
+			got = append(got, summary.ID)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("sorted by %s, got %v, want %v", by, got, want)
+		}
+	}
+	for _, summary := range logs.TransactionSummaries() {
+		if measured := summary.Duration != nil; measured != (summary.ID != "skewed") {
+			t.Errorf("got duration %v for %s", summary.Duration, summary.ID)
+		}
+	}
+	if err := SortTransactionSummaries(nil, "size"); err == nil {
+		t.Error("got no error for an unknown sort order")
+	}
+}
+
+func TestNormalizedTransaction(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", 2*time.Second),
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", 0),
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", 0),
+		newLog("a", "webserver", "/login", "DEBUG", "Checking password", time.Second),
+		newLog("a", "webserver", "/login", "INFO", "END Logging in user", 2*time.Second),
+		newLog("b", "webserver", "/login", "INFO", "START Logging in user", 0),
+	}
+	got := []string{}
+	for _, log := range logs.NormalizedTransaction("a") {
+		got = append(got, log.Message)
+	}
+	want := []string{"START Logging in user", "Checking password", "END Logging in user"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestTransactionReportUnmeasured(t *testing.T) {
+	defer func() { MeasureTransaction = OutermostDuration }()
+	MeasureTransaction = OperationSpan("GetUser", "GetUser")
+	logs := transaction("a", 0, time.Second)
+	report, ok := logs.TransactionReport("a")
+	if !ok {
+		t.Fatal("got no report for transaction a")
+	}
+	for _, result := range report.Results {
+		if result.Name == "duration" && result.Value != nil {
+			t.Errorf("got duration %v for an unmeasured transaction, want none", result.Value)
+		}
+	}
+}
+
+func TestHerdStarts(t *testing.T) {
+	logs := Logs{}
+	for i, offset := range []time.Duration{0, 100, 200, 300, 5000, 9000, 9100, 9200} {
+		logs = append(logs, transaction(string(rune('a'+i)), offset*time.Millisecond, time.Second)...)
+	}
+	got := logs.HerdStarts(500*time.Millisecond, 3)
+	want := []time.Time{testStart, testStart.Add(9 * time.Second)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, window := range []time.Duration{-time.Second, 0} {
+		for _, minCount := range []int{-1, 0} {
+			// Returning at all shows the search always advances
+			logs.HerdStarts(window, minCount)
+		}
+	}
+}
+
+func TestMaxFanoutTransaction(t *testing.T) {
+	logs := Logs{
+		newLog("narrow", "loadbalancer", "GET", "INFO", "START /index requested", 0),
+		newLog("narrow", "loadbalancer", "GET", "INFO", "END /index requested", time.Second),
+		newLog("wide", "loadbalancer", "GET", "INFO", "START /account requested", 0),
+		newLog("wide", "webserver", "/account", "INFO", "START Retrieving account information", 0),
+		newLog("wide", "account_service", "GetAccount", "INFO", "START Retrieving user account information", 0),
+		newLog("wide", "webserver", "/account", "INFO", "END Retrieving account information", time.Second),
+		newLog("tied", "loadbalancer", "GET", "INFO", "START /account requested", 0),
+		newLog("tied", "webserver", "/account", "INFO", "START Retrieving account information", 0),
+	}
+	if id, count := logs.MaxFanoutTransaction(); id != "wide" || count != 3 {
+		t.Errorf("got %s touching %d services, want wide touching 3", id, count)
+	}
+	tied := logs.Filter(Not(ByService("account_service")))
+	if id, count := tied.MaxFanoutTransaction(); id != "tied" || count != 2 {
+		t.Errorf("got %s touching %d services, want tied touching 2", id, count)
+	}
+}
+
+func TestDurationHistogram(t *testing.T) {
+	logs := Logs{}
+	logs = append(logs, transaction("a", 0, 1200*time.Millisecond)...)
+	logs = append(logs, transaction("b", 0, 1900*time.Millisecond)...)
+	logs = append(logs, transaction("c", 0, 3500*time.Millisecond)...)
+	want := map[time.Duration]int{time.Second: 2, 3 * time.Second: 1}
+	if got := logs.DurationHistogram(time.Second); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	empty := Logs{}
+	if got := empty.DurationHistogram(time.Second); len(got) != 0 {
+		t.Errorf("got %v without transactions, want an empty histogram", got)
+	}
+}
+
+func TestSegmentByBoundary(t *testing.T) {
+	logs := Logs{
+		newLog("", "webserver", "warmup", "INFO", "Loading config", 0),
+		newLog("", "webserver", "request", "INFO", "START Handling request", time.Second),
+		newLog("", "webserver", "render", "INFO", "Rendering page", 2*time.Second),
+		newLog("", "webserver", "request", "INFO", "END Handling request", 3*time.Second),
+		newLog("", "webserver", "request", "INFO", "START Handling request", 4*time.Second),
+		newLog("", "db_service", "request", "INFO", "START Handling request", 4*time.Second),
+		newLog("kept", "webserver", "request", "INFO", "START Handling request", 5*time.Second),
+	}
+	got := []string{}
+	for _, log := range logs.SegmentByBoundary("request") {
+		got = append(got, log.TransactionID)
+	}
+	want := []string{"webserver#0", "webserver#1", "webserver#1", "webserver#1", "webserver#2", "db_service#1", "kept"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if logs[0].TransactionID != "" {
+		t.Error("segmenting changed the original logs")
+	}
+}
+
+func TestSplitReusedTransactionIDs(t *testing.T) {
+	logs := transaction("reused", 0, time.Second)
+	logs = append(logs, transaction("reused", time.Hour, 2*time.Second)...)
+	logs = append(logs, transaction("reused", 2*time.Hour, 3*time.Second)...)
+	// A real transaction already has the ID the second part would be named
+	logs = append(logs, transaction("reused#2", 0, 4*time.Second)...)
+	split := logs.SplitReusedTransactionIDs(time.Minute)
+	durations := map[string]time.Duration{}
+	for id, list := range split {
+		durations[id], _ = OutermostDuration(list)
+	}
+	want := map[string]time.Duration{
+		"reused":   time.Second,
+		"reused#2": 4 * time.Second,
+		"reused#3": 2 * time.Second,
+		"reused#4": 3 * time.Second,
+	}
+	if !reflect.DeepEqual(durations, want) {
+		t.Errorf("got %v, want %v", durations, want)
+	}
+}
+
+func TestTransactionsFullyWithin(t *testing.T) {
+	logs := Logs{}
+	logs = append(logs, transaction("inside", 2*time.Second, time.Second)...)
+	logs = append(logs, transaction("edges", time.Second, 4*time.Second)...)
+	logs = append(logs, transaction("started-before", 0, 2*time.Second)...)
+	logs = append(logs, transaction("ended-after", 4*time.Second, 2*time.Second)...)
+	logs = append(logs, transaction("outside", 10*time.Second, time.Second)...)
+	got := logs.TransactionsFullyWithin(testStart.Add(time.Second), testStart.Add(5*time.Second))
+	if want := []string{"edges", "inside"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
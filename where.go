@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LogFields read each field of a Log that a where expression can compare,
+// indexed by the field's JSON name
+var LogFields = map[string]func(log *Log) string{
+	"service":        func(log *Log) string { return log.Service },
+	"level":          func(log *Log) string { return log.Level },
+	"operation":      func(log *Log) string { return log.Operation },
+	"message":        func(log *Log) string { return log.Message },
+	"transaction_id": func(log *Log) string { return log.TransactionID },
+}
+
+// ParseWhere parses a where expression into a Predicate. An expression is a
+// list of comparisons joined by AND or OR, in any case, such as
+// "service=payments AND level=ERROR or level=WARNING". Each comparison is a
+// field, = or !=, and a value, which must be quoted if it contains =, AND or
+// OR. AND binds tighter than OR, and there are no parentheses
+func ParseWhere(expression string) (Predicate, error) {
+	alternatives := []Predicate{}
+	for _, alternative := range splitKeyword(expression, "OR") {
+		conditions := []Predicate{}
+		for _, comparison := range splitKeyword(alternative, "AND") {
+			condition, err := parseComparison(comparison)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, condition)
+		}
+		alternatives = append(alternatives, All(conditions...))
+	}
+	return Any(alternatives...), nil
+}
+
+// splitKeyword splits expression around each keyword, in any case, that
+// stands on its own between spaces outside of a quoted value
+func splitKeyword(expression, keyword string) []string {
+	separator := " " + keyword + " "
+	parts := []string{}
+	var quote byte
+	start := 0
+	for i := 0; i < len(expression); i++ {
+		switch {
+		case quote != 0:
+			if expression[i] == quote {
+				quote = 0
+			}
+		case expression[i] == '"' || expression[i] == '\'':
+			quote = expression[i]
+		case i+len(separator) <= len(expression) && strings.EqualFold(expression[i:i+len(separator)], separator):
+			parts = append(parts, expression[start:i])
+			i += len(separator) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, expression[start:])
+}
+
+// parseComparison parses a single field=value or field!=value comparison
+func parseComparison(comparison string) (Predicate, error) {
+	operator := "="
+	index := strings.Index(comparison, "=")
+	if index > 0 && comparison[index-1] == '!' {
+		operator = "!="
+		index--
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("comparison %q must be of the form field=value or field!=value", comparison)
+	}
+	name := strings.TrimSpace(comparison[:index])
+	field, ok := LogFields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q in comparison %q", name, comparison)
+	}
+	value := strings.TrimSpace(comparison[index+len(operator):])
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	} else if strings.ContainsAny(value, `="'`) {
+		return nil, fmt.Errorf("value in comparison %q must be quoted", comparison)
+	}
+	if operator == "!=" {
+		return func(log *Log) bool {
+			return field(log) != value
+		}, nil
+	}
+	return func(log *Log) bool {
+		return field(log) == value
+	}, nil
+}
+
+// All returns a Predicate matching the logs that every one of predicates matches
+func All(predicates ...Predicate) Predicate {
+	return func(log *Log) bool {
+		for _, predicate := range predicates {
+			if !predicate(log) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any returns a Predicate matching the logs that at least one of predicates matches
+func Any(predicates ...Predicate) Predicate {
+	return func(log *Log) bool {
+		for _, predicate := range predicates {
+			if predicate(log) {
+				return true
+			}
+		}
+		return false
+	}
+}
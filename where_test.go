@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWhere(t *testing.T) {
+	logs := Logs{
+		newLog("a", "webserver", "/login", "ERROR", "Login failed", 0),
+		newLog("a", "webserver", "/login", "WARNING", "Slow login", time.Second),
+		newLog("a", "webserver", "/login", "INFO", "START Logging in user", 2*time.Second),
+		newLog("b", "db_service", "GetUser", "ERROR", "Timed out", 3*time.Second),
+		newLog("b", "db_service", "GetUser", "INFO", "a=b and c", 4*time.Second),
+	}
+	tests := []struct {
+		expression string
+		want       int
+	}{
+		{"service=webserver AND level=ERROR", 1},
+		{"service=webserver and level!=INFO", 2},
+		{"level=ERROR OR level=WARNING", 3},
+		{"service=db_service And level=ERROR or level=WARNING", 2},
+		{`message="a=b and c"`, 1},
+		{"message='Login failed' OR message='Slow login'", 2},
+	}
+	for _, test := range tests {
+		keep, err := ParseWhere(test.expression)
+		if err != nil {
+			t.Errorf("ParseWhere(%q): %v", test.expression, err)
+			continue
+		}
+		if got := len(logs.Filter(keep)); got != test.want {
+			t.Errorf("ParseWhere(%q) kept %d logs, want %d", test.expression, got, test.want)
+		}
+	}
+}
+
+func TestParseWhereErrors(t *testing.T) {
+	for _, expression := range []string{
+		"level",
+		"severity=ERROR",
+		"service=webserver level=ERROR",
+		"message=a=b",
+	} {
+		if _, err := ParseWhere(expression); err == nil {
+			t.Errorf("ParseWhere(%q) returned no error", expression)
+		}
+	}
+}